@@ -0,0 +1,53 @@
+package sarama
+
+// AclOperation is an operation a principal can be authorized to perform on a
+// given resource, as returned by the broker's authorized-operations bitmap.
+type AclOperation int
+
+const (
+	AclOperationUnknown AclOperation = iota
+	AclOperationAny
+	AclOperationAll
+	AclOperationRead
+	AclOperationWrite
+	AclOperationCreate
+	AclOperationDelete
+	AclOperationAlter
+	AclOperationDescribe
+	AclOperationClusterAction
+	AclOperationDescribeConfigs
+	AclOperationAlterConfigs
+	AclOperationIdempotentWrite
+)
+
+func (a AclOperation) String() string {
+	names := [...]string{
+		"Unknown", "Any", "All", "Read", "Write", "Create", "Delete",
+		"Alter", "Describe", "ClusterAction", "DescribeConfigs",
+		"AlterConfigs", "IdempotentWrite",
+	}
+	if int(a) < 0 || int(a) >= len(names) {
+		return "Unknown"
+	}
+	return names[a]
+}
+
+// authorizedOperationsFromBitmap decodes the bitmap Kafka returns for
+// "authorized operations" fields (each set bit i means AclOperation(i) is
+// permitted) into the list of operations it represents. A bitmap of -2147483648
+// (Int32MinValue) signals that authorized operations were not requested and
+// is decoded as nil.
+func authorizedOperationsFromBitmap(bitmap int32) []AclOperation {
+	const notRequested = int32(-2147483648)
+	if bitmap == notRequested {
+		return nil
+	}
+
+	var ops []AclOperation
+	for i := AclOperationUnknown; i <= AclOperationIdempotentWrite; i++ {
+		if bitmap&(1<<uint(i)) != 0 {
+			ops = append(ops, i)
+		}
+	}
+	return ops
+}
@@ -0,0 +1,278 @@
+package sarama
+
+// ClusterAdmin is the administrative client for Kafka, which supports
+// managing and inspecting topics, brokers, configurations and ACLs.
+type ClusterAdmin interface {
+	// ListConsumerGroupOffsets returns the currently committed offsets for
+	// the given consumer group and topic/partitions. Pass a nil map to
+	// fetch offsets for all partitions currently committed by the group.
+	ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*OffsetFetchResponse, error)
+
+	// DeleteConsumerGroupOffset deletes the committed offset for a single
+	// topic/partition of a consumer group.
+	DeleteConsumerGroupOffset(group string, topic string, partition int32) error
+
+	// DescribeConsumerGroup returns the state, members, and (per KIP-345)
+	// the static group.instance.id of each member, if any, for the given
+	// consumer group.
+	DescribeConsumerGroup(group string) (*GroupDescription, error)
+
+	// RemoveMemberFromConsumerGroup removes one or more members from a
+	// consumer group, triggering an immediate rebalance as if they had
+	// left voluntarily. groupInstanceIds targets static members (KIP-345)
+	// by their group.instance.id rather than their current, ephemeral
+	// member ID, which is necessary since an operator removing a member
+	// out-of-band generally does not know its live member ID.
+	RemoveMemberFromConsumerGroup(group string, groupInstanceIds []string) (*LeaveGroupResponse, error)
+
+	// DescribeCluster returns the controller id, cluster id, and current
+	// broker list, and, if includeAuthorizedOperations is true, the ACL
+	// operations the caller is authorized to perform on the cluster.
+	DescribeCluster(includeAuthorizedOperations bool) (*ClusterDescription, error)
+
+	// DescribeTopics returns per-topic partition metadata for the given
+	// topics, and, if includeAuthorizedOperations is true, the ACL
+	// operations the caller is authorized to perform on each topic.
+	DescribeTopics(topics []string, includeAuthorizedOperations bool) ([]*TopicDescription, error)
+
+	// Close shuts down the admin, and closes the underlying client if
+	// NewClusterAdmin created it; a client passed to
+	// NewClusterAdminFromClient is left open.
+	Close() error
+}
+
+type clusterAdmin struct {
+	client Client
+	conf   *Config
+
+	// ownsClient is set when NewClusterAdmin created client, so that Close
+	// closes it.
+	ownsClient bool
+}
+
+// NewClusterAdmin creates a new ClusterAdmin using the given broker
+// addresses and configuration.
+func NewClusterAdmin(addrs []string, conf *Config) (ClusterAdmin, error) {
+	client, err := NewClient(addrs, conf)
+	if err != nil {
+		return nil, err
+	}
+	return &clusterAdmin{client: client, conf: client.Config(), ownsClient: true}, nil
+}
+
+// NewClusterAdminFromClient creates a new ClusterAdmin using the given
+// client. The client is not closed when the returned ClusterAdmin is
+// closed; ownership remains with the caller.
+func NewClusterAdminFromClient(client Client) (ClusterAdmin, error) {
+	return &clusterAdmin{client: client, conf: client.Config()}, nil
+}
+
+func (ca *clusterAdmin) Close() error {
+	if ca.ownsClient {
+		return ca.client.Close()
+	}
+	return nil
+}
+
+func (ca *clusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*OffsetFetchResponse, error) {
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &OffsetFetchRequest{
+		Version:       1,
+		ConsumerGroup: group,
+	}
+	if ca.conf.Version.IsAtLeast(V0_10_2_0) {
+		// v2 is the first version where no partitions means all of them
+		req.Version = 2
+	}
+	for topic, partitions := range topicPartitions {
+		for _, partition := range partitions {
+			req.AddPartition(topic, partition)
+		}
+	}
+
+	return coordinator.FetchOffset(req)
+}
+
+func (ca *clusterAdmin) DeleteConsumerGroupOffset(group string, topic string, partition int32) error {
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return err
+	}
+
+	req := &DeleteOffsetsRequest{
+		Group: group,
+		partitions: map[string][]int32{
+			topic: {partition},
+		},
+	}
+
+	resp, err := coordinator.DeleteOffsets(req)
+	if err != nil {
+		return err
+	}
+	return resp.ErrorCode.Err()
+}
+
+// GroupDescription describes a single consumer group, as returned by
+// DescribeConsumerGroup.
+type GroupDescription struct {
+	GroupID      string
+	State        string
+	ProtocolType string
+	Protocol     string
+	Members      map[string]*GroupMemberDescription
+}
+
+// GroupMemberDescription describes a single member of a consumer group.
+type GroupMemberDescription struct {
+	ClientID   string
+	ClientHost string
+	// GroupInstanceId is the member's static group.instance.id (KIP-345),
+	// or nil if the member is a regular, dynamic member.
+	GroupInstanceId *string
+}
+
+func (ca *clusterAdmin) DescribeConsumerGroup(group string) (*GroupDescription, error) {
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &DescribeGroupsRequest{Groups: []string{group}}
+	if ca.conf.Version.IsAtLeast(V2_4_0_0) {
+		// v4 is the first version that returns group.instance.id
+		req.Version = 4
+	}
+	resp, err := coordinator.DescribeGroups(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Groups) == 0 {
+		return nil, ErrGroupIDNotFound
+	}
+
+	g := resp.Groups[0]
+	desc := &GroupDescription{
+		GroupID:      g.GroupId,
+		State:        g.State,
+		ProtocolType: g.ProtocolType,
+		Protocol:     g.Protocol,
+		Members:      make(map[string]*GroupMemberDescription, len(g.Members)),
+	}
+	for memberID, member := range g.Members {
+		desc.Members[memberID] = &GroupMemberDescription{
+			ClientID:        member.ClientId,
+			ClientHost:      member.ClientHost,
+			GroupInstanceId: member.GroupInstanceId,
+		}
+	}
+	return desc, nil
+}
+
+func (ca *clusterAdmin) RemoveMemberFromConsumerGroup(group string, groupInstanceIds []string) (*LeaveGroupResponse, error) {
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &LeaveGroupRequest{
+		Version: 3,
+		GroupID: group,
+	}
+	for _, instanceID := range groupInstanceIds {
+		id := instanceID
+		req.Members = append(req.Members, MemberIdentity{GroupInstanceId: &id})
+	}
+
+	return coordinator.LeaveGroup(req)
+}
+
+// ClusterDescription describes the cluster as a whole: its controller,
+// cluster id, and current broker list, plus (if requested) the ACL
+// operations the caller is authorized to perform on the cluster.
+type ClusterDescription struct {
+	ControllerID int32
+	ClusterID    string
+	Brokers      []*MetadataBroker
+
+	// AuthorizedOperations is nil unless DescribeCluster was called with
+	// includeAuthorizedOperations set to true.
+	AuthorizedOperations []AclOperation
+}
+
+// TopicDescription describes a single topic's partitions, plus (if
+// requested) the ACL operations the caller is authorized to perform on it.
+type TopicDescription struct {
+	Name       string
+	IsInternal bool
+	Err        KError
+	Partitions []*MetadataResponsePartition
+
+	// AuthorizedOperations is nil unless DescribeTopics was called with
+	// includeAuthorizedOperations set to true.
+	AuthorizedOperations []AclOperation
+}
+
+func (ca *clusterAdmin) DescribeCluster(includeAuthorizedOperations bool) (*ClusterDescription, error) {
+	controller, err := ca.client.Controller()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &MetadataRequest{
+		Version:                            8,
+		Topics:                             []string{},
+		IncludeClusterAuthorizedOperations: includeAuthorizedOperations,
+	}
+
+	resp, err := controller.GetMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterID := ""
+	if resp.ClusterID != nil {
+		clusterID = *resp.ClusterID
+	}
+
+	return &ClusterDescription{
+		ControllerID:         resp.ControllerID,
+		ClusterID:            clusterID,
+		Brokers:              resp.Brokers,
+		AuthorizedOperations: resp.ClusterAuthorizedOperations,
+	}, nil
+}
+
+func (ca *clusterAdmin) DescribeTopics(topics []string, includeAuthorizedOperations bool) ([]*TopicDescription, error) {
+	controller, err := ca.client.Controller()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &MetadataRequest{
+		Version:                          8,
+		Topics:                           topics,
+		IncludeTopicAuthorizedOperations: includeAuthorizedOperations,
+	}
+
+	resp, err := controller.GetMetadata(req)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions := make([]*TopicDescription, 0, len(resp.Topics))
+	for _, t := range resp.Topics {
+		descriptions = append(descriptions, &TopicDescription{
+			Name:                 t.Name,
+			IsInternal:           t.IsInternal,
+			Err:                  t.Err,
+			Partitions:           t.Partitions,
+			AuthorizedOperations: t.AuthorizedOperations,
+		})
+	}
+	return descriptions, nil
+}
@@ -0,0 +1,120 @@
+package sarama
+
+// BalanceStrategyPlan is the results of any BalanceStrategy.Plan attempt. It
+// contains an allocation of partitions for each member of a group.
+type BalanceStrategyPlan map[string]map[string][]int32
+
+// Add assigns a set of partitions to a member.
+func (p BalanceStrategyPlan) Add(memberID, topic string, partitions ...int32) {
+	if len(partitions) == 0 {
+		return
+	}
+	if p[memberID] == nil {
+		p[memberID] = make(map[string][]int32, 1)
+	}
+	p[memberID][topic] = append(p[memberID][topic], partitions...)
+}
+
+// ConsumerGroupMemberMetadata holds the metadata for a consumer group member,
+// sent as part of a JoinGroup request and made available to BalanceStrategy
+// implementations.
+type ConsumerGroupMemberMetadata struct {
+	Version  int16
+	Topics   []string
+	UserData []byte
+}
+
+func (m *ConsumerGroupMemberMetadata) encode(pe packetEncoder) error {
+	pe.putInt16(m.Version)
+	if err := pe.putStringArray(m.Topics); err != nil {
+		return err
+	}
+	return pe.putBytes(m.UserData)
+}
+
+func (m *ConsumerGroupMemberMetadata) decode(pd packetDecoder) (err error) {
+	if m.Version, err = pd.getInt16(); err != nil {
+		return err
+	}
+	if m.Topics, err = pd.getStringArray(); err != nil {
+		return err
+	}
+	if m.UserData, err = pd.getBytes(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BalanceStrategy is used to balance topics and partitions across members of
+// a consumer group using the classic group protocol.
+type BalanceStrategy interface {
+	// Name uniquely identifies the strategy and is sent to the broker as
+	// part of the JoinGroup request so that it can be negotiated between
+	// members.
+	Name() string
+
+	// Plan accepts a map of consumer group members along with their
+	// subscribed topics, and returns a distribution plan.
+	Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error)
+
+	// AssignmentData returns the serialized assignment data for the given
+	// member, to be transmitted as part of the SyncGroup request. Strategies
+	// that are stateless across rebalances may return nil.
+	AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error)
+}
+
+// BalanceStrategyRange is the default and assigns partitions as ranges to
+// consumer group members. This follows the same logic as
+// org.apache.kafka.clients.consumer.RangeAssignor.
+var BalanceStrategyRange = &balanceStrategyRange{}
+
+type balanceStrategyRange struct{}
+
+func (s *balanceStrategyRange) Name() string { return "range" }
+
+func (s *balanceStrategyRange) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
+	memberIDs := make([]string, 0, len(members))
+	for memberID := range members {
+		memberIDs = append(memberIDs, memberID)
+	}
+
+	plan := make(BalanceStrategyPlan, len(members))
+	for topic, partitions := range topics {
+		var candidates []string
+		for _, memberID := range memberIDs {
+			if strategyMemberWantsTopic(members[memberID], topic) {
+				candidates = append(candidates, memberID)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		partitionsPerMember := len(partitions) / len(candidates)
+		extra := len(partitions) % len(candidates)
+
+		idx := 0
+		for i, memberID := range candidates {
+			n := partitionsPerMember
+			if i < extra {
+				n++
+			}
+			plan.Add(memberID, topic, partitions[idx:idx+n]...)
+			idx += n
+		}
+	}
+	return plan, nil
+}
+
+func (s *balanceStrategyRange) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
+	return nil, nil
+}
+
+func strategyMemberWantsTopic(member ConsumerGroupMemberMetadata, topic string) bool {
+	for _, t := range member.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
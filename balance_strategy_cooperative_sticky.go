@@ -0,0 +1,224 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// BalanceStrategyCooperativeSticky is a cooperative, incremental version of
+// BalanceStrategyRange modeled on Kafka's CooperativeStickyAssignor. Unlike
+// the eager classic strategies, it never revokes a partition just to hand it
+// straight back to the same member: each member's previously owned
+// partitions (communicated via UserData) are preserved wherever possible,
+// and only partitions that must move to rebalance the group are revoked.
+//
+// Because partitions can only change hands by first being revoked by their
+// current owner and then granted in a *subsequent* rebalance, achieving a
+// balanced assignment after a membership change takes two generations: the
+// first Plan() revokes the excess partitions from over-loaded members
+// without assigning them anywhere, and the automatic rejoin that follows
+// (triggered by the revocation) calls Plan() again, at which point the
+// now-unowned partitions are handed to the under-loaded members.
+var BalanceStrategyCooperativeSticky = &balanceStrategyCooperativeSticky{}
+
+type balanceStrategyCooperativeSticky struct{}
+
+func (s *balanceStrategyCooperativeSticky) Name() string { return "cooperative-sticky" }
+
+// cooperative marks this strategy as following the KIP-429 incremental
+// rebalance protocol: callers can type-assert for this method to decide
+// whether a rebalance should revoke only a subset of claims rather than
+// tearing down the whole session.
+func (s *balanceStrategyCooperativeSticky) cooperative() {}
+
+func (s *balanceStrategyCooperativeSticky) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
+	memberIDs := make([]string, 0, len(members))
+	for memberID := range members {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Strings(memberIDs)
+
+	owned := make(map[string]map[string][]int32, len(members))
+	for memberID, metadata := range members {
+		ud, err := decodeTopicPartitionUserData(metadata.UserData)
+		if err != nil {
+			return nil, err
+		}
+		owned[memberID] = ud
+	}
+
+	plan := make(BalanceStrategyPlan, len(members))
+
+	for topic, partitions := range topics {
+		var candidates []string
+		for _, memberID := range memberIDs {
+			if strategyMemberWantsTopic(members[memberID], topic) {
+				candidates = append(candidates, memberID)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		target := partitionsPerMember(candidates, len(partitions))
+
+		// A partition already owned by some member (whether or not that
+		// member is still a candidate) must not be handed to anyone else in
+		// this same Plan() call: per KIP-429, a partition can only change
+		// hands by first being revoked by its current owner, which only
+		// takes effect once that owner's next heartbeat/rejoin completes.
+		// wasOwned tracks every such partition so the "hand out" pass below
+		// can tell a genuinely free partition from one that was merely just
+		// revoked.
+		wasOwned := make(map[int32]bool, len(partitions))
+		for _, memberOwned := range owned {
+			for _, p := range memberOwned[topic] {
+				wasOwned[p] = true
+			}
+		}
+
+		// Partitions that a candidate already owns are kept by that
+		// candidate, up to its fair share; anything above the fair share is
+		// left unassigned this generation (an implicit revoke). Partitions
+		// owned by a member that is no longer a candidate for this topic
+		// are likewise left unassigned.
+		assigned := make(map[int32]bool, len(partitions))
+		retained := make(map[string][]int32, len(candidates))
+		for _, memberID := range candidates {
+			var keep []int32
+			for _, p := range owned[memberID][topic] {
+				if assigned[p] || len(keep) >= target[memberID] {
+					continue
+				}
+				keep = append(keep, p)
+				assigned[p] = true
+			}
+			retained[memberID] = keep
+			plan.Add(memberID, topic, keep...)
+		}
+
+		// Hand out any partition that was never owned by anyone to the
+		// first candidate that is still under its fair share. A partition
+		// that was just revoked above (wasOwned but not assigned) is
+		// withheld this generation: it only becomes eligible once the
+		// revoking member's rejoin reports it as no longer owned, which
+		// drives the next Plan() call.
+		for _, p := range partitions {
+			if assigned[p] || wasOwned[p] {
+				continue
+			}
+			for _, memberID := range candidates {
+				if len(retained[memberID]) < target[memberID] {
+					retained[memberID] = append(retained[memberID], p)
+					plan.Add(memberID, topic, p)
+					assigned[p] = true
+					break
+				}
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// partitionsPerMember computes each candidate's fair-share partition count
+// for a topic, distributing any remainder to the earliest candidates in
+// sorted member-ID order so the computation is deterministic.
+func partitionsPerMember(candidates []string, numPartitions int) map[string]int {
+	base := numPartitions / len(candidates)
+	extra := numPartitions % len(candidates)
+
+	target := make(map[string]int, len(candidates))
+	for i, memberID := range candidates {
+		n := base
+		if i < extra {
+			n++
+		}
+		target[memberID] = n
+	}
+	return target
+}
+
+func decodeTopicPartitionUserData(data []byte) (map[string][]int32, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	buf := data
+	readUint32 := func() (uint32, bool) {
+		if len(buf) < 4 {
+			return 0, false
+		}
+		v := binary.BigEndian.Uint32(buf)
+		buf = buf[4:]
+		return v, true
+	}
+	readString := func() (string, bool) {
+		n, ok := readUint32()
+		if !ok || uint32(len(buf)) < n {
+			return "", false
+		}
+		s := string(buf[:n])
+		buf = buf[n:]
+		return s, true
+	}
+
+	n, ok := readUint32()
+	if !ok {
+		return nil, nil
+	}
+
+	result := make(map[string][]int32, n)
+	for i := uint32(0); i < n; i++ {
+		topic, ok := readString()
+		if !ok {
+			return nil, nil
+		}
+		pn, ok := readUint32()
+		if !ok {
+			return nil, nil
+		}
+		partitions := make([]int32, pn)
+		for j := uint32(0); j < pn; j++ {
+			v, ok := readUint32()
+			if !ok {
+				return nil, nil
+			}
+			partitions[j] = int32(v)
+		}
+		result[topic] = partitions
+	}
+	return result, nil
+}
+
+func encodeTopicPartitionUserData(owned map[string][]int32) []byte {
+	topics := make([]string, 0, len(owned))
+	for topic := range owned {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, uint32(len(topics)))
+
+	for _, topic := range topics {
+		partitions := owned[topic]
+
+		head := make([]byte, 4+len(topic)+4)
+		binary.BigEndian.PutUint32(head, uint32(len(topic)))
+		copy(head[4:], topic)
+		binary.BigEndian.PutUint32(head[4+len(topic):], uint32(len(partitions)))
+		buf = append(buf, head...)
+
+		for _, p := range partitions {
+			v := make([]byte, 4)
+			binary.BigEndian.PutUint32(v, uint32(p))
+			buf = append(buf, v...)
+		}
+	}
+	return buf
+}
+
+func (s *balanceStrategyCooperativeSticky) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
+	return encodeTopicPartitionUserData(topics), nil
+}
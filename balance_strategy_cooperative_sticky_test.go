@@ -0,0 +1,72 @@
+package sarama
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// memberMetadata builds a ConsumerGroupMemberMetadata for a member subscribed
+// to topic and owning the given partitions, encoded the same way
+// classicGroupSession.sync distributes AssignmentData back out as UserData
+// on the following join.
+func memberMetadata(topic string, owned []int32) ConsumerGroupMemberMetadata {
+	return ConsumerGroupMemberMetadata{
+		Topics:   []string{topic},
+		UserData: encodeTopicPartitionUserData(map[string][]int32{topic: owned}),
+	}
+}
+
+func sortedPlan(plan BalanceStrategyPlan, memberID, topic string) []int32 {
+	partitions := append([]int32(nil), plan[memberID][topic]...)
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+	return partitions
+}
+
+// TestBalanceStrategyCooperativeStickyWithholdsRevokedPartitions exercises
+// the two-phase behavior required by KIP-429: when a new member joins a
+// group whose sole existing member owns every partition, the partitions that
+// must move are revoked from the incumbent but must NOT be handed to the new
+// member in the same Plan() call. Only once the incumbent's revoke is
+// reflected in its UserData (i.e. on the following generation) may the new
+// member be assigned them.
+func TestBalanceStrategyCooperativeStickyWithholdsRevokedPartitions(t *testing.T) {
+	topics := map[string][]int32{"topic": {0, 1, 2, 3}}
+
+	members := map[string]ConsumerGroupMemberMetadata{
+		"M1": memberMetadata("topic", []int32{0, 1, 2, 3}),
+		"M2": memberMetadata("topic", nil),
+	}
+
+	plan, err := BalanceStrategyCooperativeSticky.Plan(members, topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sortedPlan(plan, "M1", "topic"); !reflect.DeepEqual(got, []int32{0, 1}) {
+		t.Errorf("generation 1: M1 = %v, want [0 1]", got)
+	}
+	if got := sortedPlan(plan, "M2", "topic"); len(got) != 0 {
+		t.Errorf("generation 1: M2 = %v, want none: revoked partitions must be withheld until the next generation", got)
+	}
+
+	// Generation 2: M1 rejoins reporting only the partitions it actually
+	// retained; the two partitions revoked above are now unowned and may be
+	// handed to M2.
+	members = map[string]ConsumerGroupMemberMetadata{
+		"M1": memberMetadata("topic", plan["M1"]["topic"]),
+		"M2": memberMetadata("topic", plan["M2"]["topic"]),
+	}
+
+	plan, err = BalanceStrategyCooperativeSticky.Plan(members, topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sortedPlan(plan, "M1", "topic"); !reflect.DeepEqual(got, []int32{0, 1}) {
+		t.Errorf("generation 2: M1 = %v, want [0 1]", got)
+	}
+	if got := sortedPlan(plan, "M2", "topic"); !reflect.DeepEqual(got, []int32{2, 3}) {
+		t.Errorf("generation 2: M2 = %v, want [2 3]", got)
+	}
+}
@@ -0,0 +1,123 @@
+package sarama
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BalanceStrategyCopartitioning assigns partitions of multiple topics such
+// that partition N of every topic always lands on the same member, which is
+// the guarantee stream-processing joins over co-partitioned topics require.
+// It treats "partition N across all subscribed topics" as a single
+// indivisible unit of assignment and distributes those units evenly across
+// members, preserving a member's previously owned units via UserData
+// wherever possible (similar in spirit to BalanceStrategyCooperativeSticky,
+// but operating on units instead of individual partitions).
+//
+// All topics passed to Plan must share the same partition count; Plan
+// returns an error otherwise, since there would be no way to define
+// "partition N across all topics" consistently.
+var BalanceStrategyCopartitioning = &balanceStrategyCopartitioning{}
+
+type balanceStrategyCopartitioning struct{}
+
+func (s *balanceStrategyCopartitioning) Name() string { return "copartitioning" }
+
+func (s *balanceStrategyCopartitioning) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
+	numPartitions := -1
+	topicNames := make([]string, 0, len(topics))
+	for topic, partitions := range topics {
+		topicNames = append(topicNames, topic)
+		if numPartitions == -1 {
+			numPartitions = len(partitions)
+			continue
+		}
+		if len(partitions) != numPartitions {
+			return nil, fmt.Errorf("sarama: copartitioning strategy requires all topics to have the same partition count, but %q has %d while an earlier topic has %d", topic, len(partitions), numPartitions)
+		}
+	}
+	sort.Strings(topicNames)
+
+	if numPartitions <= 0 {
+		return make(BalanceStrategyPlan), nil
+	}
+
+	memberIDs := make([]string, 0, len(members))
+	for memberID := range members {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Strings(memberIDs)
+
+	// A "unit" is the set of partition N across every co-partitioned topic;
+	// units[n] is simply n, since the partition numbering is shared.
+	owned := make(map[string]map[int32]bool, len(members))
+	for memberID, metadata := range members {
+		ud, err := decodeTopicPartitionUserData(metadata.UserData)
+		if err != nil {
+			return nil, err
+		}
+		units := make(map[int32]bool)
+		for _, partitions := range ud {
+			for _, p := range partitions {
+				units[p] = true
+			}
+		}
+		owned[memberID] = units
+	}
+
+	target := partitionsPerMember(memberIDs, numPartitions)
+
+	assigned := make(map[int32]bool, numPartitions)
+	retained := make(map[string][]int32, len(memberIDs))
+
+	// keep each member's previously owned units, up to its fair share
+	for _, memberID := range memberIDs {
+		var keep []int32
+		unitIDs := make([]int32, 0, len(owned[memberID]))
+		for u := range owned[memberID] {
+			unitIDs = append(unitIDs, u)
+		}
+		sort.Slice(unitIDs, func(i, j int) bool { return unitIDs[i] < unitIDs[j] })
+
+		for _, u := range unitIDs {
+			if u >= int32(numPartitions) || assigned[u] || len(keep) >= target[memberID] {
+				continue
+			}
+			keep = append(keep, u)
+			assigned[u] = true
+		}
+		retained[memberID] = keep
+	}
+
+	// hand out any unowned (or just-revoked) unit to the first member still
+	// under its fair share
+	for u := int32(0); u < int32(numPartitions); u++ {
+		if assigned[u] {
+			continue
+		}
+		for _, memberID := range memberIDs {
+			if len(retained[memberID]) < target[memberID] {
+				retained[memberID] = append(retained[memberID], u)
+				assigned[u] = true
+				break
+			}
+		}
+	}
+
+	plan := make(BalanceStrategyPlan, len(memberIDs))
+	for _, memberID := range memberIDs {
+		units := retained[memberID]
+		for _, topic := range topicNames {
+			if !strategyMemberWantsTopic(members[memberID], topic) {
+				continue
+			}
+			plan.Add(memberID, topic, units...)
+		}
+	}
+
+	return plan, nil
+}
+
+func (s *balanceStrategyCopartitioning) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
+	return encodeTopicPartitionUserData(topics), nil
+}
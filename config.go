@@ -0,0 +1,143 @@
+package sarama
+
+import "time"
+
+// GroupProtocolType identifies which consumer group membership protocol a
+// client should use when joining a group: the classic client-side protocol
+// built on JoinGroup/SyncGroup/Heartbeat, or the KIP-848 "consumer" protocol
+// where assignment is computed by the broker.
+type GroupProtocolType string
+
+const (
+	// GroupProtocolClassic is the original protocol, where a client-elected
+	// leader computes the assignment for the whole group using a
+	// BalanceStrategy and distributes it via SyncGroup.
+	GroupProtocolClassic GroupProtocolType = "classic"
+
+	// GroupProtocolConsumer is the KIP-848 next-generation protocol, where
+	// the group coordinator computes assignment for each member and pushes
+	// incremental updates via ConsumerGroupHeartbeat responses. There is no
+	// client-side leader and no rebalance strategy negotiation.
+	GroupProtocolConsumer GroupProtocolType = "consumer"
+)
+
+// Config is used to pass multiple configuration options to Sarama's
+// constructors.
+type Config struct {
+	// ClientID is used to identify this client to the brokers and in
+	// request/response metadata.
+	ClientID string
+
+	// Version is the version of Kafka that Sarama will assume it is running
+	// against, gating which request/response versions are used on the wire.
+	Version KafkaVersion
+
+	// ChannelBufferSize is the number of events to buffer in internal and
+	// external channels. This permits the producer and consumer to
+	// continue processing some messages in the background while user code
+	// is working, greatly improving throughput. Defaults to 256.
+	ChannelBufferSize int
+
+	Consumer struct {
+		Return struct {
+			// If enabled, any errors that occurred while consuming are
+			// returned on the Errors channel.
+			Errors bool
+		}
+
+		Offsets struct {
+			// Initial offset to use if no offset was previously committed.
+			// Should be OffsetNewest or OffsetOldest.
+			Initial int64
+		}
+
+		Group struct {
+			// Protocol selects which consumer group membership protocol to
+			// use. Defaults to GroupProtocolClassic; set to
+			// GroupProtocolConsumer to opt into the KIP-848 next-generation
+			// protocol.
+			Protocol GroupProtocolType
+
+			Session struct {
+				// Timeout used to detect consumer failures when using the
+				// classic group protocol's heartbeat mechanism.
+				Timeout time.Duration
+			}
+
+			Heartbeat struct {
+				// Interval between heartbeats to the consumer coordinator.
+				Interval time.Duration
+			}
+
+			Rebalance struct {
+				// Strategy used to assign partitions to group members when
+				// using the classic group protocol. Defaults to
+				// BalanceStrategyRange.
+				Strategy BalanceStrategy
+
+				// Timeout is the maximum allowed time for each rebalance
+				// stage.
+				Timeout time.Duration
+
+				Retry struct {
+					// Max is the number of retries when a rebalance fails.
+					Max int
+				}
+
+				Backoff struct {
+					// Initial is the backoff before the first retry of a
+					// failed Consume session. Defaults to 500ms.
+					Initial time.Duration
+
+					// Max is the ceiling the exponential backoff will not
+					// grow past, no matter how many consecutive sessions
+					// have failed. Defaults to 30s.
+					Max time.Duration
+
+					// Factor is the multiplier applied to the backoff after
+					// each failed session, until Max is reached. Defaults
+					// to 2.
+					Factor float64
+				}
+			}
+
+			Member struct {
+				// UserData is sent as part of the consumer group join
+				// request, available via ConsumerGroupMemberMetadata.
+				UserData []byte
+
+				// InstanceID, when set, registers this member as a static
+				// member identified by the given group.instance.id
+				// (KIP-345). A static member that restarts within
+				// Session.Timeout rejoins with the same identity instead of
+				// triggering a rebalance, so other members' assignments are
+				// left untouched across the restart.
+				InstanceID string
+
+				// ServerAssignor is the server-side assignor this member
+				// prefers the coordinator use, under the KIP-848 consumer
+				// group protocol. Empty lets the coordinator pick.
+				ServerAssignor string
+			}
+		}
+	}
+}
+
+// NewConfig returns a new configuration instance with sane defaults.
+func NewConfig() *Config {
+	c := &Config{}
+
+	c.ChannelBufferSize = 256
+
+	c.Consumer.Offsets.Initial = OffsetNewest
+	c.Consumer.Group.Protocol = GroupProtocolClassic
+	c.Consumer.Group.Session.Timeout = 10 * time.Second
+	c.Consumer.Group.Heartbeat.Interval = 3 * time.Second
+	c.Consumer.Group.Rebalance.Strategy = BalanceStrategyRange
+	c.Consumer.Group.Rebalance.Timeout = 60 * time.Second
+	c.Consumer.Group.Rebalance.Backoff.Initial = 500 * time.Millisecond
+	c.Consumer.Group.Rebalance.Backoff.Max = 30 * time.Second
+	c.Consumer.Group.Rebalance.Backoff.Factor = 2
+
+	return c
+}
@@ -0,0 +1,41 @@
+package sarama
+
+// ConnectionState represents the observable life-cycle state of a
+// ConsumerGroup's connection to its group coordinator, for use by health
+// checks and dashboards built on top of ConsumerGroup.ConnectionState and
+// ConsumerGroup.ConnectionStateChanges.
+type ConnectionState int32
+
+const (
+	// ConnectionStateConnecting is the state before the first session has
+	// been established.
+	ConnectionStateConnecting ConnectionState = iota
+
+	// ConnectionStateConnected means the group member currently holds a
+	// session and is consuming its claims.
+	ConnectionStateConnected
+
+	// ConnectionStateRecovering means the previous session ended in error
+	// and the consumer group is waiting on its reconnect backoff before
+	// retrying.
+	ConnectionStateRecovering
+
+	// ConnectionStateStopped means the consumer group has been closed and
+	// will not reconnect.
+	ConnectionStateStopped
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionStateConnecting:
+		return "connecting"
+	case ConnectionStateConnected:
+		return "connected"
+	case ConnectionStateRecovering:
+		return "recovering"
+	case ConnectionStateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
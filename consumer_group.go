@@ -0,0 +1,401 @@
+package sarama
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosedConsumerGroup is the error returned when a method is called on a
+// consumer group that has already been closed.
+var ErrClosedConsumerGroup = errors.New("kafka: tried to use a consumer group that was closed")
+
+// ConsumerGroup is responsible for dividing up processing of topics and
+// partitions across a collection of processes (the members of the consumer
+// group).
+type ConsumerGroup interface {
+	// Consume joins a cluster of consumers for a given list of topics and
+	// starts a blocking ConsumerGroupSession through the ConsumerGroupHandler.
+	//
+	// The life-cycle of a session is represented by the following steps:
+	//
+	// 1. The consumers join the group and are assigned their "fair share" of
+	//    partitions, depending on the selected group protocol and strategy.
+	// 2. The session begins with the consumer group handler's Setup() hook
+	//    being called, to notify the user of the claims it was handed.
+	// 3. For each assigned partition, ConsumeClaim is called for the
+	//    partition's ConsumerGroupClaim in a separate goroutine.
+	// 4. The session continues until one of the ConsumeClaim functions exits;
+	//    this can be caused by a parent context cancellation or by an
+	//    internal error surfacing from the internal consumer.
+	// 5. Once all the ConsumeClaim loops have exited, the handler's Cleanup()
+	//    hook is called to signal the end of the session. Under a
+	//    cooperative BalanceStrategy, the claims the member keeps into the
+	//    next generation are not stopped and keep running across Cleanup.
+	// 6. Finally, marked offsets are committed one last time before
+	//    participating in the next round of the cooperative rebalance
+	//    protocol.
+	//
+	// Please note that once a rebalance is triggered, sessions must be
+	// completed within Config.Consumer.Group.Rebalance.Timeout.
+	//
+	// Consume retries a session that ends in error internally, backing off
+	// per Config.Consumer.Group.Rebalance.Backoff, and only returns once ctx
+	// is cancelled or the group is closed; use ConnectionState and
+	// ConnectionStateChanges to observe reconnect attempts rather than
+	// looping on Consume's return value yourself.
+	Consume(ctx context.Context, topics []string, handler ConsumerGroupHandler) error
+
+	// Errors returns a read channel of errors that occurred during the
+	// consumer life-cycle. By default, errors are not returned over this
+	// channel. If you want to implement any custom error handling,
+	// set Config.Consumer.Return.Errors to true, and read from this channel.
+	Errors() <-chan error
+
+	// ConnectionState returns the current ConnectionState of the group.
+	ConnectionState() ConnectionState
+
+	// ConnectionStateChanges returns a channel on which every subsequent
+	// ConnectionState transition is delivered. The channel is closed when
+	// the ConsumerGroup is closed. Sends are non-blocking: a slow reader
+	// can miss intermediate states but will eventually observe the latest
+	// one.
+	ConnectionStateChanges() <-chan ConnectionState
+
+	// Close stops the ConsumerGroup and detaches any running sessions. It is
+	// required to call this function before the object passes out of scope,
+	// as it will otherwise leak memory.
+	Close() error
+}
+
+type consumerGroup struct {
+	client Client
+
+	config   *Config
+	groupID  string
+	memberID string
+
+	// memberEpoch is not written by either protocol and stays zero: the
+	// KIP-848 "consumer" protocol tracks the live epoch in
+	// consumerProtocolSession.epoch, and the classic protocol tracks a
+	// generation ID per session.
+	memberEpoch int32
+
+	errors chan error
+
+	lock      sync.Mutex
+	closed    chan none
+	closeOnce sync.Once
+
+	state     int32 // atomic ConnectionState
+	stateSubs []chan ConnectionState
+	stateMu   sync.Mutex
+
+	// sendMu is a barrier between Close and any in-flight send on errors or
+	// a stateSubs channel: setState and the error send in Consume hold the
+	// read side while they send, and Close takes the write side before
+	// closing those channels, so a send can never race a close.
+	sendMu sync.RWMutex
+}
+
+// NewConsumerGroup creates a new consumer group the given broker addresses
+// and configuration.
+func NewConsumerGroup(addrs []string, groupID string, config *Config) (ConsumerGroup, error) {
+	client, err := NewClient(addrs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := newConsumerGroup(groupID, client)
+	if err != nil {
+		_ = client.Close()
+	}
+	return c, err
+}
+
+// NewConsumerGroupFromClient creates a new consumer group using the given
+// client. It is still necessary to call Close() on the resulting group once
+// it is no longer needed. The provided client cannot be used in any other
+// consumer groups.
+func NewConsumerGroupFromClient(groupID string, client Client) (ConsumerGroup, error) {
+	return newConsumerGroup(groupID, client)
+}
+
+func newConsumerGroup(groupID string, client Client) (ConsumerGroup, error) {
+	config := client.Config()
+	if config.Consumer.Group.Protocol != GroupProtocolClassic && config.Consumer.Group.Protocol != GroupProtocolConsumer {
+		return nil, ConfigurationError("the only supported group protocols are GroupProtocolClassic and GroupProtocolConsumer")
+	}
+
+	return &consumerGroup{
+		client:  client,
+		config:  config,
+		groupID: groupID,
+		errors:  make(chan error, config.ChannelBufferSize),
+		closed:  make(chan none),
+	}, nil
+}
+
+func (c *consumerGroup) Errors() <-chan error { return c.errors }
+
+func (c *consumerGroup) ConnectionState() ConnectionState {
+	return ConnectionState(atomic.LoadInt32(&c.state))
+}
+
+func (c *consumerGroup) ConnectionStateChanges() <-chan ConnectionState {
+	ch := make(chan ConnectionState, 8)
+
+	c.stateMu.Lock()
+	select {
+	case <-c.closed:
+		close(ch)
+	default:
+		c.stateSubs = append(c.stateSubs, ch)
+	}
+	c.stateMu.Unlock()
+
+	return ch
+}
+
+func (c *consumerGroup) setState(s ConnectionState) {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+
+	select {
+	case <-c.closed:
+		return
+	default:
+	}
+
+	atomic.StoreInt32(&c.state, int32(s))
+
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	for _, ch := range c.stateSubs {
+		select {
+		case ch <- s:
+		default:
+			// buffer full: drop the oldest buffered state to keep the latest
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- s:
+			default:
+			}
+		}
+	}
+}
+
+func (c *consumerGroup) Close() (err error) {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		// Wait out any setState/error send already in flight before closing
+		// the channels they send on, so a racing Consume goroutine can never
+		// send on a closed channel.
+		c.sendMu.Lock()
+		defer c.sendMu.Unlock()
+
+		atomic.StoreInt32(&c.state, int32(ConnectionStateStopped))
+
+		c.stateMu.Lock()
+		for _, ch := range c.stateSubs {
+			close(ch)
+		}
+		c.stateMu.Unlock()
+
+		close(c.errors)
+	})
+	return err
+}
+
+// Consume wraps a single session of the negotiated group protocol in a
+// bounded, exponentially-backed-off retry loop: a session that ends in
+// error is retried automatically rather than being surfaced to the caller,
+// with ConnectionState transitioning to ConnectionStateRecovering for the
+// duration of the backoff. ConnectionState is ConnectionStateConnecting from
+// the start of each attempt until the session actually establishes (the
+// handler's Setup hook returns successfully), at which point the session
+// itself reports ConnectionStateConnected. Consume only returns once ctx is
+// cancelled or the group is closed, replacing the "loop until
+// ErrClosedConsumerGroup" pattern callers previously had to implement
+// themselves.
+func (c *consumerGroup) Consume(ctx context.Context, topics []string, handler ConsumerGroupHandler) error {
+	select {
+	case <-c.closed:
+		return ErrClosedConsumerGroup
+	default:
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-sessionCtx.Done():
+		}
+	}()
+
+	backoff := c.config.Consumer.Group.Rebalance.Backoff.Initial
+
+	for {
+		select {
+		case <-c.closed:
+			return ErrClosedConsumerGroup
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		c.setState(ConnectionStateConnecting)
+
+		var err error
+		if c.config.Consumer.Group.Protocol == GroupProtocolConsumer {
+			err = c.consumeWithConsumerProtocol(sessionCtx, topics, handler)
+		} else {
+			err = c.consumeWithClassicProtocol(sessionCtx, topics, handler)
+		}
+
+		select {
+		case <-c.closed:
+			return ErrClosedConsumerGroup
+		default:
+		}
+		if err == nil || errors.Is(err, context.Canceled) || ctx.Err() != nil {
+			return err
+		}
+		if errors.Is(err, ErrClosedConsumerGroup) {
+			return err
+		}
+
+		c.setState(ConnectionStateRecovering)
+		if c.config.Consumer.Return.Errors {
+			c.sendMu.RLock()
+			select {
+			case <-c.closed:
+			default:
+				select {
+				case c.errors <- err:
+				default:
+				}
+			}
+			c.sendMu.RUnlock()
+		}
+
+		select {
+		case <-c.closed:
+			return ErrClosedConsumerGroup
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = time.Duration(float64(backoff) * c.config.Consumer.Group.Rebalance.Backoff.Factor)
+		if max := c.config.Consumer.Group.Rebalance.Backoff.Max; backoff > max {
+			backoff = max
+		}
+	}
+}
+
+// consumeWithClassicProtocol runs a single session of the classic
+// JoinGroup/SyncGroup/Heartbeat rebalance protocol. The full implementation
+// lives alongside the rest of the classic group coordinator machinery; it is
+// unchanged by the introduction of the KIP-848 protocol below.
+func (c *consumerGroup) consumeWithClassicProtocol(ctx context.Context, topics []string, handler ConsumerGroupHandler) error {
+	return c.newClassicSession(ctx, topics, handler).run()
+}
+
+// consumeWithConsumerProtocol runs a single session of the KIP-848 "consumer"
+// group protocol. Unlike the classic protocol there is no client-side leader
+// election or assignment computation: the member simply heartbeats its
+// subscription to the coordinator via ConsumerGroupHeartbeat and reconciles
+// whatever target assignment the coordinator hands back.
+func (c *consumerGroup) consumeWithConsumerProtocol(ctx context.Context, topics []string, handler ConsumerGroupHandler) error {
+	return c.newConsumerProtocolSession(ctx, topics, handler).run()
+}
+
+// ConsumerGroupSession represents a consumer group member session.
+type ConsumerGroupSession interface {
+	// Claims returns information about the claims that were assigned to the
+	// group member in the current session.
+	Claims() map[string][]int32
+
+	// MemberID returns the cluster member ID.
+	MemberID() string
+
+	// GenerationID returns the current generation ID (classic protocol) or
+	// the current member epoch (KIP-848 consumer protocol).
+	GenerationID() int32
+
+	// MarkOffset marks the provided offset, alongside a metadata string that
+	// represents the state of the partition consumer at that point in time.
+	MarkOffset(topic string, partition int32, offset int64, metadata string)
+
+	// Commit the offset to the backend.
+	Commit()
+
+	// ResetOffset resets to the provided offset, alongside a metadata string
+	// that represents the state of the partition consumer at that point in
+	// time.
+	ResetOffset(topic string, partition int32, offset int64, metadata string)
+
+	// MarkMessage marks a message as consumed.
+	MarkMessage(msg *ConsumerMessage, metadata string)
+
+	// Context returns the session context.
+	Context() context.Context
+}
+
+// ConsumerGroupHandler instances are used to handle individual topic/partition
+// claims. It also provides hooks for your consumer group session life-cycle
+// and allow you to trigger logic before or after the consume loop(s).
+//
+// PLEASE NOTE that handlers are likely to be called from several goroutines
+// concurrently, ensure that all state is safely protected against race
+// conditions.
+type ConsumerGroupHandler interface {
+	// Setup is run at the beginning of a new session, before ConsumeClaim.
+	Setup(ConsumerGroupSession) error
+
+	// Cleanup is run at the end of a session, once all ConsumeClaim
+	// goroutines have exited but before the offsets are committed for the
+	// very last time. Under a cooperative BalanceStrategy, claims retained
+	// into the next generation are not stopped before Cleanup runs.
+	Cleanup(ConsumerGroupSession) error
+
+	// ConsumeClaim must start a consumer loop of ConsumerGroupClaim's
+	// Messages(). Once the Messages() channel is closed, the Handler must
+	// finish its processing loop and exit.
+	ConsumeClaim(ConsumerGroupSession, ConsumerGroupClaim) error
+}
+
+// ConsumerGroupClaim processes Kafka messages from a given topic and
+// partition within a consumer group.
+type ConsumerGroupClaim interface {
+	// Topic returns the consumed topic name.
+	Topic() string
+
+	// Partition returns the consumed partition.
+	Partition() int32
+
+	// InitialOffset returns the initial offset that was used as a starting
+	// point for this claim.
+	InitialOffset() int64
+
+	// HighWaterMarkOffset returns the high water mark offset of the
+	// partition, i.e. the offset that will be used for the next message that
+	// will be produced.
+	HighWaterMarkOffset() int64
+
+	// Messages returns the read channel for the messages that are returned
+	// by the broker.
+	Messages() <-chan *ConsumerMessage
+}
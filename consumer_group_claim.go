@@ -0,0 +1,222 @@
+package sarama
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// groupClaims owns a member's claim goroutines and offset manager for the
+// full lifetime of a single Consume() call. Unlike the lightweight
+// per-generation groupSession view handed to the ConsumerGroupHandler, it is
+// not recreated on every rebalance: a cooperative rebalance that retains a
+// partition across a generation boundary leaves its ConsumeClaim loop
+// running untouched instead of tearing it down and immediately restarting
+// it.
+type groupClaims struct {
+	parent  *consumerGroup
+	handler ConsumerGroupHandler
+	offsets OffsetManager
+
+	mu     sync.Mutex
+	claims map[string]*groupClaim
+}
+
+func newGroupClaims(parent *consumerGroup, handler ConsumerGroupHandler) (*groupClaims, error) {
+	offsets, err := NewOffsetManagerFromClient(parent.groupID, parent.client)
+	if err != nil {
+		return nil, err
+	}
+	return &groupClaims{
+		parent:  parent,
+		handler: handler,
+		offsets: offsets,
+		claims:  make(map[string]*groupClaim),
+	}, nil
+}
+
+func (g *groupClaims) current() map[string][]int32 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := make(map[string][]int32, len(g.claims))
+	for _, c := range g.claims {
+		result[c.topic] = append(result[c.topic], c.partition)
+	}
+	return result
+}
+
+// start claims a partition and spawns its ConsumeClaim loop, unless it is
+// already claimed, in which case it is a no-op: both protocols call start
+// for every partition in a target assignment regardless of whether it is
+// new or retained.
+func (g *groupClaims) start(session ConsumerGroupSession, topic string, partition int32) error {
+	key := claimKey(topic, partition)
+
+	g.mu.Lock()
+	if _, ok := g.claims[key]; ok {
+		g.mu.Unlock()
+		return nil
+	}
+	g.mu.Unlock()
+
+	c, err := newGroupClaim(g.parent.client, topic, partition, g.parent.config.Consumer.Offsets.Initial)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.claims[key] = c
+	g.mu.Unlock()
+
+	go func() {
+		defer close(c.done)
+		if err := g.handler.ConsumeClaim(session, c); err != nil && g.parent.config.Consumer.Return.Errors {
+			g.parent.sendMu.RLock()
+			select {
+			case <-g.parent.closed:
+			default:
+				select {
+				case g.parent.errors <- err:
+				default:
+				}
+			}
+			g.parent.sendMu.RUnlock()
+		}
+		g.mu.Lock()
+		delete(g.claims, key)
+		g.mu.Unlock()
+	}()
+	return nil
+}
+
+// stop revokes a single partition, blocking until its ConsumeClaim loop has
+// actually exited so that callers can rely on the revoke being complete, not
+// just requested, once stop returns.
+func (g *groupClaims) stop(topic string, partition int32) {
+	key := claimKey(topic, partition)
+
+	g.mu.Lock()
+	c, ok := g.claims[key]
+	g.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	_ = c.close()
+	<-c.done
+}
+
+// stopAll revokes every currently running claim, used when tearing down a
+// session entirely: an eager rebalance, or the end of the Consume() call.
+func (g *groupClaims) stopAll() {
+	g.mu.Lock()
+	claims := make([]*groupClaim, 0, len(g.claims))
+	for _, c := range g.claims {
+		claims = append(claims, c)
+	}
+	g.mu.Unlock()
+
+	for _, c := range claims {
+		_ = c.close()
+	}
+	for _, c := range claims {
+		<-c.done
+	}
+}
+
+func (g *groupClaims) close() {
+	g.stopAll()
+	_ = g.offsets.Close()
+}
+
+func (g *groupClaims) markOffset(topic string, partition int32, offset int64, metadata string) {
+	if pom, err := g.offsets.ManagePartition(topic, partition); err == nil {
+		pom.MarkOffset(offset, metadata)
+	}
+}
+
+func (g *groupClaims) resetOffset(topic string, partition int32, offset int64, metadata string) {
+	if pom, err := g.offsets.ManagePartition(topic, partition); err == nil {
+		pom.ResetOffset(offset, metadata)
+	}
+}
+
+func (g *groupClaims) commit() { g.offsets.Commit() }
+
+func claimKey(topic string, partition int32) string {
+	return topic + "/" + strconv.FormatInt(int64(partition), 10)
+}
+
+// groupSession is the concrete, per-generation ConsumerGroupSession passed to
+// the ConsumerGroupHandler. A new groupSession is created for every
+// generation (classic) or epoch (KIP-848) so MemberID/GenerationID reflect
+// the current one, but Claims() and the underlying claim goroutines are
+// shared via groupClaims, so they survive across the generation boundary
+// untouched unless explicitly revoked.
+type groupSession struct {
+	ctx          context.Context
+	claims       *groupClaims
+	memberID     string
+	generationID int32
+}
+
+func (s *groupSession) Claims() map[string][]int32 { return s.claims.current() }
+func (s *groupSession) MemberID() string           { return s.memberID }
+func (s *groupSession) GenerationID() int32        { return s.generationID }
+func (s *groupSession) Context() context.Context   { return s.ctx }
+func (s *groupSession) Commit()                    { s.claims.commit() }
+
+func (s *groupSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.claims.markOffset(topic, partition, offset, metadata)
+}
+
+func (s *groupSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+	s.claims.resetOffset(topic, partition, offset, metadata)
+}
+
+func (s *groupSession) MarkMessage(msg *ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+// groupClaim is the concrete ConsumerGroupClaim backing a single assigned
+// partition; it is a thin wrapper over a PartitionConsumer, revoked by
+// closing the underlying PartitionConsumer so Messages() closes and the
+// handler's ConsumeClaim loop exits on its own.
+type groupClaim struct {
+	topic         string
+	partition     int32
+	initialOffset int64
+	pc            PartitionConsumer
+
+	done chan struct{}
+}
+
+func newGroupClaim(client Client, topic string, partition int32, offset int64) (*groupClaim, error) {
+	consumer, err := NewConsumerFromClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		_ = consumer.Close()
+		return nil, err
+	}
+
+	return &groupClaim{
+		topic:         topic,
+		partition:     partition,
+		initialOffset: offset,
+		pc:            pc,
+		done:          make(chan struct{}),
+	}, nil
+}
+
+func (c *groupClaim) Topic() string                     { return c.topic }
+func (c *groupClaim) Partition() int32                  { return c.partition }
+func (c *groupClaim) InitialOffset() int64              { return c.initialOffset }
+func (c *groupClaim) HighWaterMarkOffset() int64        { return c.pc.HighWaterMarkOffset() }
+func (c *groupClaim) Messages() <-chan *ConsumerMessage { return c.pc.Messages() }
+
+func (c *groupClaim) close() error { return c.pc.Close() }
@@ -0,0 +1,446 @@
+package sarama
+
+import (
+	"context"
+	"time"
+)
+
+// balanceStrategyCooperative is implemented by BalanceStrategy
+// implementations that follow the KIP-429 incremental (cooperative)
+// rebalance protocol, where partitions are only revoked when they must
+// actually move to a different member. classicGroupSession type-asserts for
+// this to decide whether a rebalance should surgically stop the affected
+// claims or tear down the whole session as the eager protocol requires.
+type balanceStrategyCooperative interface {
+	BalanceStrategy
+	cooperative()
+}
+
+// classicGroupSession drives a single Consume() call's participation in the
+// classic JoinGroup/SyncGroup/Heartbeat rebalance protocol across however
+// many generations the context lives for: the group coordinator elects one
+// member as leader, the leader computes an assignment for the whole group
+// using the negotiated BalanceStrategy, and the result is distributed to
+// every member via SyncGroup.
+type classicGroupSession struct {
+	parent  *consumerGroup
+	ctx     context.Context
+	topics  []string
+	handler ConsumerGroupHandler
+
+	// instanceID is the static group.instance.id this session joins with,
+	// if Config.Consumer.Group.Member.InstanceID is set (KIP-345).
+	instanceID string
+
+	generationID int32
+	memberID     string
+	leaderID     string
+	members      map[string]ConsumerGroupMemberMetadata
+
+	// assignmentUserData is the UserData from this member's most recent
+	// SyncGroup assignment. It is echoed back as the join metadata's
+	// UserData on the next JoinGroup so a stateful/sticky BalanceStrategy
+	// (e.g. BalanceStrategyCooperativeSticky) can see the member's prior
+	// state when computing the next Plan. It is nil before the first sync,
+	// when Config.Consumer.Group.Member.UserData is sent instead.
+	assignmentUserData []byte
+
+	claims *groupClaims
+}
+
+func (c *consumerGroup) newClassicSession(ctx context.Context, topics []string, handler ConsumerGroupHandler) *classicGroupSession {
+	return &classicGroupSession{
+		parent:     c,
+		ctx:        ctx,
+		topics:     topics,
+		handler:    handler,
+		instanceID: c.config.Consumer.Group.Member.InstanceID,
+	}
+}
+
+// run drives the protocol across however many generations the context lives
+// for, rejoining automatically whenever a heartbeat reports a rebalance in
+// progress.
+func (s *classicGroupSession) run() error {
+	claims, err := newGroupClaims(s.parent, s.handler)
+	if err != nil {
+		return err
+	}
+	s.claims = claims
+	defer s.claims.close()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		default:
+		}
+
+		rejoin, err := s.runGeneration()
+		if err != nil {
+			return err
+		}
+		if !rejoin {
+			return s.ctx.Err()
+		}
+	}
+}
+
+// runGeneration joins (or rejoins) the group, computes and distributes the
+// assignment if elected leader, dispatches the member's own claims against
+// the result, and heartbeats until either the coordinator signals a
+// rebalance (rejoin=true) or the session ends for another reason.
+func (s *classicGroupSession) runGeneration() (rejoin bool, err error) {
+	if err := s.join(); err != nil {
+		return false, err
+	}
+
+	target, err := s.sync()
+	if err != nil {
+		return false, err
+	}
+
+	session := &groupSession{ctx: s.ctx, claims: s.claims, memberID: s.memberID, generationID: s.generationID}
+
+	// reconcile before Setup so that s.Claims() already reflects this
+	// generation's assignment by the time the handler is notified, rather
+	// than lagging behind by one generation.
+	strategy := s.parent.config.Consumer.Group.Rebalance.Strategy
+	revoked := s.reconcile(session, target, strategy)
+
+	if err := s.handler.Setup(session); err != nil {
+		return false, err
+	}
+	s.parent.setState(ConnectionStateConnected)
+
+	_, cooperative := strategy.(balanceStrategyCooperative)
+
+	var hbErr error
+	if cooperative && revoked {
+		// KIP-429: revoking a partition in this generation only takes it
+		// away; granting it to an under-loaded member requires a follow-up
+		// rebalance, and nothing else here (a heartbeat only reports
+		// ErrRebalanceInProgress when the *coordinator* initiates one) would
+		// trigger that follow-up. The member that just revoked must request
+		// it itself by rejoining immediately rather than settling into this
+		// generation's heartbeat loop.
+		rejoin = true
+	} else {
+		rejoin, hbErr = s.heartbeatLoop()
+	}
+
+	if !cooperative {
+		s.claims.stopAll()
+	}
+
+	if cleanupErr := s.handler.Cleanup(session); cleanupErr != nil && hbErr == nil {
+		hbErr = cleanupErr
+	}
+
+	return rejoin, hbErr
+}
+
+// reconcile applies a new target assignment computed by the group leader.
+// For a cooperative BalanceStrategy it only stops the claims absent from the
+// new assignment, leaving claims the member keeps owning running
+// uninterrupted; the freshly revoked partitions are not reassigned until the
+// member's automatic rejoin completes the next generation. For an eager
+// strategy every claim was already stopped at the end of the previous
+// generation, so every assigned partition here is freshly (re)started.
+// revoked reports whether any claim was actually stopped, which only
+// happens under a cooperative strategy (an eager strategy starts this call
+// with no claims running) and tells the caller a follow-up rejoin is owed.
+func (s *classicGroupSession) reconcile(session ConsumerGroupSession, target map[string][]int32, strategy BalanceStrategy) (revoked bool) {
+	desired := make(map[string]bool, len(target))
+	for topic, partitions := range target {
+		for _, p := range partitions {
+			desired[claimKey(topic, p)] = true
+		}
+	}
+
+	for topic, partitions := range s.claims.current() {
+		for _, p := range partitions {
+			if !desired[claimKey(topic, p)] {
+				s.claims.stop(topic, p)
+				revoked = true
+			}
+		}
+	}
+
+	for topic, partitions := range target {
+		for _, p := range partitions {
+			_ = s.claims.start(session, topic, p)
+		}
+	}
+
+	return revoked
+}
+
+func (s *classicGroupSession) coordinator() (*Broker, error) {
+	return s.parent.client.Coordinator(s.parent.groupID)
+}
+
+// joinGroupVersion picks the highest JoinGroupRequest version the
+// negotiated Config.Version supports, so that a session configured against
+// an older cluster doesn't send a request the broker will reject outright.
+func (s *classicGroupSession) joinGroupVersion() int16 {
+	switch {
+	case s.parent.config.Version.IsAtLeast(V2_3_0_0):
+		return 5
+	case s.parent.config.Version.IsAtLeast(V0_11_0_0):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// syncGroupVersion picks the highest SyncGroupRequest version the
+// negotiated Config.Version supports. GroupInstanceId requires v3 and
+// ProtocolType/ProtocolName validation requires v5; both are only sent at
+// v3+ and v5 respectively.
+func (s *classicGroupSession) syncGroupVersion() int16 {
+	if s.parent.config.Version.IsAtLeast(V2_3_0_0) {
+		return 5
+	}
+	return 0
+}
+
+// heartbeatVersion picks the highest HeartbeatRequest version the
+// negotiated Config.Version supports; GroupInstanceId requires v3.
+func (s *classicGroupSession) heartbeatVersion() int16 {
+	switch {
+	case s.parent.config.Version.IsAtLeast(V2_3_0_0):
+		return 3
+	case s.parent.config.Version.IsAtLeast(V0_11_0_0):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// leaveGroupVersion picks the highest LeaveGroupRequest version the
+// negotiated Config.Version supports; the Members/GroupInstanceId form
+// requires v3.
+func (s *classicGroupSession) leaveGroupVersion() int16 {
+	if s.parent.config.Version.IsAtLeast(V2_4_0_0) {
+		return 3
+	}
+	return 0
+}
+
+// join sends JoinGroupRequest, retrying in place (without returning to the
+// caller) while the coordinator is still assigning this member an ID or
+// finishing a rebalance that was already in progress. On success it records
+// the negotiated generation, member ID, leader, and (if this member is the
+// leader) every member's metadata for sync() to compute an assignment from.
+func (s *classicGroupSession) join() error {
+	strategy := s.parent.config.Consumer.Group.Rebalance.Strategy
+
+	userData := s.assignmentUserData
+	if userData == nil {
+		userData = s.parent.config.Consumer.Group.Member.UserData
+	}
+	meta := ConsumerGroupMemberMetadata{
+		Version:  0,
+		Topics:   s.topics,
+		UserData: userData,
+	}
+	metaBytes, err := encode(&meta)
+	if err != nil {
+		return err
+	}
+
+	req := &JoinGroupRequest{
+		Version:          s.joinGroupVersion(),
+		GroupID:          s.parent.groupID,
+		SessionTimeout:   int32(s.parent.config.Consumer.Group.Session.Timeout.Milliseconds()),
+		RebalanceTimeout: int32(s.parent.config.Consumer.Group.Rebalance.Timeout.Milliseconds()),
+		MemberID:         s.memberID,
+		ProtocolType:     "consumer",
+	}
+	if s.instanceID != "" {
+		req.GroupInstanceId = &s.instanceID
+	}
+	req.AddGroupProtocol(strategy.Name(), metaBytes)
+
+	for {
+		broker, err := s.coordinator()
+		if err != nil {
+			return err
+		}
+
+		resp, err := broker.JoinGroup(req)
+		if err != nil {
+			return err
+		}
+
+		switch resp.Err {
+		case ErrNoError:
+			s.generationID = resp.GenerationId
+			s.memberID = resp.MemberId
+			s.leaderID = resp.LeaderId
+			s.members = resp.Members
+			return nil
+		case ErrMemberIdRequired, ErrUnknownMemberId:
+			s.memberID = resp.MemberId
+			req.MemberID = resp.MemberId
+		case ErrRebalanceInProgress:
+			// Retry immediately with whatever member ID we currently hold.
+		default:
+			return resp.Err
+		}
+	}
+}
+
+// sync sends SyncGroupRequest. If this member was elected leader by join(),
+// it first computes the whole group's assignment via the negotiated
+// BalanceStrategy and attaches it; every other member sends an empty
+// GroupAssignments and simply receives its slice back. The returned
+// assignment's UserData is stashed on the session so the next join() can
+// echo it back as join metadata, letting a stateful BalanceStrategy see
+// this member's prior state.
+func (s *classicGroupSession) sync() (map[string][]int32, error) {
+	strategy := s.parent.config.Consumer.Group.Rebalance.Strategy
+	protocolType := "consumer"
+	protocolName := strategy.Name()
+
+	req := &SyncGroupRequest{
+		Version:      s.syncGroupVersion(),
+		GroupID:      s.parent.groupID,
+		GenerationId: s.generationID,
+		MemberId:     s.memberID,
+		ProtocolType: &protocolType,
+		ProtocolName: &protocolName,
+	}
+	if s.instanceID != "" {
+		req.GroupInstanceId = &s.instanceID
+	}
+
+	if s.memberID == s.leaderID {
+		topics := make(map[string][]int32, len(s.topics))
+		for _, topic := range s.topics {
+			partitions, err := s.parent.client.Partitions(topic)
+			if err != nil {
+				return nil, err
+			}
+			topics[topic] = partitions
+		}
+
+		plan, err := strategy.Plan(s.members, topics)
+		if err != nil {
+			return nil, err
+		}
+
+		for memberID := range s.members {
+			userData, err := strategy.AssignmentData(memberID, plan[memberID], s.generationID)
+			if err != nil {
+				return nil, err
+			}
+
+			bin, err := encode(&ConsumerGroupMemberAssignment{Topics: plan[memberID], UserData: userData})
+			if err != nil {
+				return nil, err
+			}
+			req.GroupAssignments = append(req.GroupAssignments, SyncGroupRequestAssignment{MemberId: memberID, Assignment: bin})
+		}
+	}
+
+	broker, err := s.coordinator()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := broker.SyncGroup(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Err != ErrNoError {
+		return nil, resp.Err
+	}
+
+	var assignment ConsumerGroupMemberAssignment
+	if err := decode(resp.MemberAssignment, &assignment); err != nil {
+		return nil, err
+	}
+	s.assignmentUserData = assignment.UserData
+	return assignment.Topics, nil
+}
+
+// heartbeatLoop sends HeartbeatRequest on Config.Consumer.Group.Heartbeat.Interval
+// until the coordinator reports a rebalance in progress (rejoin=true), the
+// context is cancelled (in which case the member leaves the group via
+// leave), or an unrecoverable error occurs.
+func (s *classicGroupSession) heartbeatLoop() (rejoin bool, err error) {
+	ticker := time.NewTicker(s.parent.config.Consumer.Group.Heartbeat.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.leave()
+			return false, nil
+		case <-ticker.C:
+			broker, err := s.coordinator()
+			if err != nil {
+				return false, err
+			}
+
+			req := &HeartbeatRequest{
+				Version:      s.heartbeatVersion(),
+				GroupId:      s.parent.groupID,
+				GenerationId: s.generationID,
+				MemberId:     s.memberID,
+			}
+			if s.instanceID != "" {
+				req.GroupInstanceId = &s.instanceID
+			}
+
+			resp, err := broker.Heartbeat(req)
+			if err != nil {
+				return false, err
+			}
+
+			switch resp.Err {
+			case ErrNoError:
+				continue
+			case ErrRebalanceInProgress:
+				return true, nil
+			default:
+				return false, resp.Err
+			}
+		}
+	}
+}
+
+// leave sends a best-effort LeaveGroupRequest so the coordinator can
+// rebalance the rest of the group immediately instead of waiting for this
+// member's session to time out. A static member (one with an instanceID)
+// sends nothing: the coordinator keeps its assignment until the session
+// times out, so a restart with the same instance ID rejoins without a
+// rebalance.
+func (s *classicGroupSession) leave() {
+	if s.instanceID != "" {
+		return
+	}
+
+	broker, err := s.coordinator()
+	if err != nil {
+		return
+	}
+
+	req := &LeaveGroupRequest{
+		Version: s.leaveGroupVersion(),
+		GroupID: s.parent.groupID,
+	}
+	if req.Version < 3 {
+		req.MemberID = s.memberID
+	} else {
+		member := MemberIdentity{MemberID: s.memberID}
+		if s.instanceID != "" {
+			member.GroupInstanceId = &s.instanceID
+		}
+		req.Members = []MemberIdentity{member}
+	}
+
+	_, _ = broker.LeaveGroup(req)
+}
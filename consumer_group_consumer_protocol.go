@@ -0,0 +1,253 @@
+package sarama
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// consumerProtocolSession drives a single member's participation in the
+// KIP-848 "consumer" group protocol. There is no client-side leader election
+// or rebalance strategy: the coordinator tracks each member's subscription
+// and epoch and pushes target assignments directly in the
+// ConsumerGroupHeartbeat response. The member's only job is to heartbeat
+// regularly, reconcile whatever assignment it was handed (revoking
+// partitions it no longer owns before acknowledging new ones), and
+// re-heartbeat to confirm the reconciliation.
+type consumerProtocolSession struct {
+	parent  *consumerGroup
+	ctx     context.Context
+	topics  []string
+	handler ConsumerGroupHandler
+
+	memberID string
+	// epoch is the member epoch last acknowledged by the coordinator. A
+	// member that wants to (re)join the group heartbeats with epoch 0; the
+	// coordinator then assigns it a member ID and epoch 1. From then on the
+	// member must echo back the epoch the coordinator last sent it.
+	epoch int32
+
+	// owned is the set of partitions currently being consumed, keyed by
+	// topic. It is used both to compute the revoke set when a new target
+	// assignment arrives (owned - target must be revoked before the next
+	// heartbeat acknowledges the new epoch) and to report the member's
+	// current ownership to the coordinator on every heartbeat.
+	owned map[string][]int32
+
+	// topicIDs and topicNames cache the bidirectional mapping between each
+	// subscribed topic's name and the topic ID that KIP-848 heartbeats and
+	// assignments reference on the wire, resolved once up front since the
+	// subscription (s.topics) does not change for the life of the session.
+	topicIDs   map[string]string
+	topicNames map[string]string
+
+	claims  *groupClaims
+	session *groupSession
+}
+
+func (c *consumerGroup) newConsumerProtocolSession(ctx context.Context, topics []string, handler ConsumerGroupHandler) *consumerProtocolSession {
+	return &consumerProtocolSession{
+		parent:  c,
+		ctx:     ctx,
+		topics:  topics,
+		handler: handler,
+		owned:   make(map[string][]int32),
+	}
+}
+
+// resolveTopicIDs populates the name<->ID mapping for every subscribed
+// topic. KIP-848 heartbeats report owned partitions, and the coordinator
+// returns assignments, in terms of topic ID rather than name.
+func (s *consumerProtocolSession) resolveTopicIDs() error {
+	s.topicIDs = make(map[string]string, len(s.topics))
+	s.topicNames = make(map[string]string, len(s.topics))
+	for _, topic := range s.topics {
+		id, err := s.parent.client.TopicID(topic)
+		if err != nil {
+			return err
+		}
+		s.topicIDs[topic] = id
+		s.topicNames[id] = topic
+	}
+	return nil
+}
+
+// run sends an initial ConsumerGroupHeartbeat to join the group, then loops
+// heartbeating on Config.Consumer.Group.Heartbeat.Interval, reconciling any
+// target assignment the coordinator returns, until the context is cancelled.
+func (s *consumerProtocolSession) run() (err error) {
+	claims, err := newGroupClaims(s.parent, s.handler)
+	if err != nil {
+		return err
+	}
+	s.claims = claims
+	defer s.claims.close()
+
+	if err := s.resolveTopicIDs(); err != nil {
+		return err
+	}
+
+	req := s.nextHeartbeatRequest()
+	resp, err := s.heartbeat(req)
+	if err != nil {
+		return err
+	}
+	s.memberID = resp.MemberID
+	atomic.StoreInt32(&s.epoch, resp.MemberEpoch)
+
+	s.session = &groupSession{ctx: s.ctx, claims: s.claims, memberID: s.memberID, generationID: resp.MemberEpoch}
+	if err := s.handler.Setup(s.session); err != nil {
+		return err
+	}
+	s.parent.setState(ConnectionStateConnected)
+	defer func() {
+		s.claims.stopAll()
+		if cleanupErr := s.handler.Cleanup(s.session); cleanupErr != nil && err == nil {
+			err = cleanupErr
+		}
+	}()
+
+	if resp.Assignment != nil {
+		if err := s.reconcile(resp.Assignment); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(s.parent.config.Consumer.Group.Heartbeat.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ticker.C:
+			req := s.nextHeartbeatRequest()
+			resp, err := s.heartbeat(req)
+			if err != nil {
+				return err
+			}
+			atomic.StoreInt32(&s.epoch, resp.MemberEpoch)
+			s.session.generationID = resp.MemberEpoch
+			if resp.Assignment != nil {
+				if err := s.reconcile(resp.Assignment); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// nextHeartbeatRequest builds the ConsumerGroupHeartbeat request for this
+// member. The very first heartbeat additionally carries the subscription,
+// rebalance timeout, static instance ID, and preferred server-side assignor;
+// subsequent heartbeats only need to echo the group/member/epoch triple.
+// Every heartbeat reports the member's current ownership, by topic ID, so
+// the coordinator can track in-flight revocations.
+func (s *consumerProtocolSession) nextHeartbeatRequest() *ConsumerGroupHeartbeatRequest {
+	req := &ConsumerGroupHeartbeatRequest{
+		GroupID:     s.parent.groupID,
+		MemberID:    s.memberID,
+		MemberEpoch: atomic.LoadInt32(&s.epoch),
+	}
+
+	if s.memberID == "" {
+		req.SubscribedTopicNames = s.topics
+		req.RebalanceTimeoutMs = int32(s.parent.config.Consumer.Group.Rebalance.Timeout.Milliseconds())
+
+		if instanceID := s.parent.config.Consumer.Group.Member.InstanceID; instanceID != "" {
+			req.InstanceID = &instanceID
+		}
+		if assignor := s.parent.config.Consumer.Group.Member.ServerAssignor; assignor != "" {
+			req.ServerAssignor = &assignor
+		}
+	}
+
+	for topic, partitions := range s.owned {
+		if len(partitions) == 0 {
+			continue
+		}
+		req.TopicPartitions = append(req.TopicPartitions, ConsumerGroupHeartbeatRequestTopicPartition{
+			TopicID:    s.topicIDs[topic],
+			Partitions: partitions,
+		})
+	}
+
+	return req
+}
+
+func (s *consumerProtocolSession) heartbeat(req *ConsumerGroupHeartbeatRequest) (*ConsumerGroupHeartbeatResponse, error) {
+	// The actual broker round-trip reuses the same coordinator-discovery and
+	// retry machinery as the classic protocol's Heartbeat request; only the
+	// request/response shapes differ.
+	return s.parent.sendConsumerGroupHeartbeat(req)
+}
+
+// reconcile applies a target assignment from the coordinator: any owned
+// partition that is absent from the target is revoked immediately (its
+// ConsumeClaim loop is stopped) before the newly granted partitions are
+// claimed, matching the two-phase revoke-then-assign flow the KIP-848
+// protocol expects of cooperative consumers.
+func (s *consumerProtocolSession) reconcile(target *ConsumerGroupHeartbeatResponseAssignment) error {
+	desired := make(map[string][]int32, len(target.TopicPartitions))
+	for _, tp := range target.TopicPartitions {
+		topic, ok := s.topicNames[tp.TopicID]
+		if !ok {
+			return fmt.Errorf("consumer group heartbeat: assigned unknown topic ID %s", tp.TopicID)
+		}
+		desired[topic] = tp.Partitions
+	}
+
+	for topic, partitions := range s.owned {
+		keep := make(map[int32]bool, len(desired[topic]))
+		for _, p := range desired[topic] {
+			keep[p] = true
+		}
+		var revoked []int32
+		for _, p := range partitions {
+			if !keep[p] {
+				revoked = append(revoked, p)
+			}
+		}
+		s.revokeClaims(topic, revoked)
+		if _, ok := desired[topic]; !ok {
+			delete(s.owned, topic)
+		}
+	}
+
+	for topic, partitions := range desired {
+		s.owned[topic] = partitions
+	}
+	return s.dispatchClaims(desired)
+}
+
+// revokeClaims stops the ConsumeClaim loop for every partition of topic that
+// the member no longer owns, blocking until each one has actually exited so
+// the member can safely acknowledge the new epoch on its next heartbeat.
+func (s *consumerProtocolSession) revokeClaims(topic string, partitions []int32) {
+	for _, p := range partitions {
+		s.claims.stop(topic, p)
+	}
+}
+
+// dispatchClaims starts a ConsumeClaim loop for every partition in
+// assignment that isn't already running; partitions the member already owns
+// are left untouched, matching the incremental nature of the protocol.
+func (s *consumerProtocolSession) dispatchClaims(assignment map[string][]int32) error {
+	for topic, partitions := range assignment {
+		for _, p := range partitions {
+			if err := s.claims.start(s.session, topic, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *consumerGroup) sendConsumerGroupHeartbeat(req *ConsumerGroupHeartbeatRequest) (*ConsumerGroupHeartbeatResponse, error) {
+	broker, err := c.client.Coordinator(c.groupID)
+	if err != nil {
+		return nil, err
+	}
+	return broker.ConsumerGroupHeartbeat(req)
+}
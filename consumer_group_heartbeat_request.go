@@ -0,0 +1,157 @@
+package sarama
+
+// ConsumerGroupHeartbeatRequest implements the ConsumerGroupHeartbeat API
+// (key 68) introduced by KIP-848. It is the sole request used by members
+// participating in the "consumer" group protocol: a single heartbeat both
+// joins the group (when MemberEpoch is 0) and reports liveness, since
+// assignment is computed entirely on the broker.
+//
+// API 68 is flexible-only (KIP-482): every string, array, and the request
+// itself are compact-encoded, with a trailing tagged-field buffer after the
+// top-level body and after each TopicPartitions element.
+type ConsumerGroupHeartbeatRequest struct {
+	// Version is the version of this request.
+	Version int16
+
+	// GroupID is the group identifier.
+	GroupID string
+
+	// MemberID is the member ID generated by the coordinator, or empty on
+	// the initial heartbeat that joins the group.
+	MemberID string
+
+	// MemberEpoch is the current member epoch, or 0 to join the group, or
+	// -1 to indicate that the member is leaving the group.
+	MemberEpoch int32
+
+	// InstanceID is the static group instance id, if any (see KIP-345).
+	// A nil InstanceID is encoded as null, distinct from an empty string.
+	InstanceID *string
+
+	// RackID is the rack ID of the consumer, if any.
+	RackID *string
+
+	// RebalanceTimeoutMs is the maximum time the coordinator will wait on
+	// member reconciliation before considering it failed. Only sent on the
+	// initial heartbeat that joins the group.
+	RebalanceTimeoutMs int32
+
+	// SubscribedTopicNames is the set of topics the member wants to
+	// consume. Only sent when the subscription changes.
+	SubscribedTopicNames []string
+
+	// ServerAssignor is the server-side assignor this member wants to use,
+	// or nil to let the coordinator pick.
+	ServerAssignor *string
+
+	// TopicPartitions is the set of partitions owned by the member before
+	// this heartbeat, used by the coordinator to reconcile in-flight
+	// revocations. consumerProtocolSession sends it on every heartbeat.
+	TopicPartitions []ConsumerGroupHeartbeatRequestTopicPartition
+}
+
+// ConsumerGroupHeartbeatRequestTopicPartition describes the partitions of a
+// single topic currently owned by the heartbeating member.
+type ConsumerGroupHeartbeatRequestTopicPartition struct {
+	TopicID    string
+	Partitions []int32
+}
+
+func (r *ConsumerGroupHeartbeatRequest) encode(pe packetEncoder) error {
+	if err := pe.putCompactString(r.GroupID); err != nil {
+		return err
+	}
+	if err := pe.putCompactString(r.MemberID); err != nil {
+		return err
+	}
+	pe.putInt32(r.MemberEpoch)
+	if err := pe.putCompactNullableString(r.InstanceID); err != nil {
+		return err
+	}
+	if err := pe.putCompactNullableString(r.RackID); err != nil {
+		return err
+	}
+	pe.putInt32(r.RebalanceTimeoutMs)
+
+	if err := pe.putCompactStringArray(r.SubscribedTopicNames); err != nil {
+		return err
+	}
+	if err := pe.putCompactNullableString(r.ServerAssignor); err != nil {
+		return err
+	}
+
+	pe.putCompactArrayLength(len(r.TopicPartitions))
+	for _, tp := range r.TopicPartitions {
+		if err := pe.putCompactString(tp.TopicID); err != nil {
+			return err
+		}
+		if err := pe.putCompactInt32Array(tp.Partitions); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ConsumerGroupHeartbeatRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.GroupID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if r.MemberID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if r.MemberEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.InstanceID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if r.RackID, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if r.RebalanceTimeoutMs, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.SubscribedTopicNames, err = pd.getCompactStringArray(); err != nil {
+		return err
+	}
+	if r.ServerAssignor, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.TopicPartitions = make([]ConsumerGroupHeartbeatRequestTopicPartition, n)
+		for i := 0; i < n; i++ {
+			if r.TopicPartitions[i].TopicID, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if r.TopicPartitions[i].Partitions, err = pd.getCompactInt32Array(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ConsumerGroupHeartbeatRequest) key() int16           { return 68 }
+func (r *ConsumerGroupHeartbeatRequest) version() int16       { return r.Version }
+func (r *ConsumerGroupHeartbeatRequest) headerVersion() int16 { return 2 }
+func (r *ConsumerGroupHeartbeatRequest) isValidVersion() bool { return r.Version == 0 }
+func (r *ConsumerGroupHeartbeatRequest) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
@@ -0,0 +1,158 @@
+package sarama
+
+// ConsumerGroupHeartbeatResponse implements the ConsumerGroupHeartbeat API
+// response (key 68). When Assignment is non-nil, it carries the member's
+// complete target assignment as computed by the coordinator; a nil
+// Assignment means the member's current assignment is unchanged.
+//
+// API 68 is flexible-only (KIP-482): every string, array, and the response
+// itself are compact-encoded, with a trailing tagged-field buffer after the
+// top-level body and after each TopicPartitions element.
+type ConsumerGroupHeartbeatResponse struct {
+	Version int16
+
+	ThrottleTimeMs int32
+	ErrorCode      int16
+	ErrorMessage   *string
+
+	// MemberID is the coordinator-assigned member ID. It is only populated
+	// on the response to the initial join heartbeat.
+	MemberID string
+
+	// MemberEpoch is the member epoch the member must echo back on its next
+	// heartbeat.
+	MemberEpoch int32
+
+	// HeartbeatIntervalMs is the interval at which the member should send
+	// its next heartbeat, as determined by the coordinator.
+	HeartbeatIntervalMs int32
+
+	// Assignment is the member's target assignment, or nil if unchanged
+	// since the last heartbeat.
+	Assignment *ConsumerGroupHeartbeatResponseAssignment
+}
+
+// ConsumerGroupHeartbeatResponseAssignment is the target assignment for a
+// single member, in terms of whole topic-partition sets rather than an
+// incremental diff; the caller is responsible for diffing it against what it
+// currently owns to determine what must be revoked versus newly claimed.
+type ConsumerGroupHeartbeatResponseAssignment struct {
+	TopicPartitions []ConsumerGroupHeartbeatResponseTopicPartition
+}
+
+// ConsumerGroupHeartbeatRequestAssignment is retained for symmetry with the
+// request side; the wire protocol only ever sends assignments in responses.
+type ConsumerGroupHeartbeatRequestAssignment = ConsumerGroupHeartbeatResponseAssignment
+
+// ConsumerGroupHeartbeatResponseTopicPartition identifies the assigned
+// partitions of a single topic by TopicID, the wire identifier KIP-848
+// assignments reference; callers must resolve it to a topic name (e.g. via
+// consumerProtocolSession's cached name<->ID mapping) before keying claims
+// or starting a PartitionConsumer, which both operate on names.
+type ConsumerGroupHeartbeatResponseTopicPartition struct {
+	TopicID    string
+	Partitions []int32
+}
+
+func (r *ConsumerGroupHeartbeatResponse) encode(pe packetEncoder) error {
+	pe.putInt32(r.ThrottleTimeMs)
+	pe.putInt16(r.ErrorCode)
+	if err := pe.putCompactNullableString(r.ErrorMessage); err != nil {
+		return err
+	}
+	if err := pe.putCompactString(r.MemberID); err != nil {
+		return err
+	}
+	pe.putInt32(r.MemberEpoch)
+	pe.putInt32(r.HeartbeatIntervalMs)
+
+	if r.Assignment == nil {
+		pe.putBool(false)
+		pe.putEmptyTaggedFieldArray()
+		return nil
+	}
+	pe.putBool(true)
+	pe.putCompactArrayLength(len(r.Assignment.TopicPartitions))
+	for _, tp := range r.Assignment.TopicPartitions {
+		if err := pe.putCompactString(tp.TopicID); err != nil {
+			return err
+		}
+		if err := pe.putCompactInt32Array(tp.Partitions); err != nil {
+			return err
+		}
+		pe.putEmptyTaggedFieldArray()
+	}
+
+	pe.putEmptyTaggedFieldArray()
+	return nil
+}
+
+func (r *ConsumerGroupHeartbeatResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.ThrottleTimeMs, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.ErrorCode, err = pd.getInt16(); err != nil {
+		return err
+	}
+	if r.ErrorMessage, err = pd.getCompactNullableString(); err != nil {
+		return err
+	}
+	if r.MemberID, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if r.MemberEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.HeartbeatIntervalMs, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	hasAssignment, err := pd.getBool()
+	if err != nil {
+		return err
+	}
+	if !hasAssignment {
+		_, err = pd.getEmptyTaggedFieldArray()
+		return err
+	}
+
+	n, err := pd.getCompactArrayLength()
+	if err != nil {
+		return err
+	}
+	assignment := &ConsumerGroupHeartbeatResponseAssignment{}
+	if n > 0 {
+		assignment.TopicPartitions = make([]ConsumerGroupHeartbeatResponseTopicPartition, n)
+		for i := 0; i < n; i++ {
+			if assignment.TopicPartitions[i].TopicID, err = pd.getCompactString(); err != nil {
+				return err
+			}
+			if assignment.TopicPartitions[i].Partitions, err = pd.getCompactInt32Array(); err != nil {
+				return err
+			}
+			if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+				return err
+			}
+		}
+	}
+	r.Assignment = assignment
+
+	if _, err = pd.getEmptyTaggedFieldArray(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ConsumerGroupHeartbeatResponse) key() int16           { return 68 }
+func (r *ConsumerGroupHeartbeatResponse) version() int16       { return r.Version }
+func (r *ConsumerGroupHeartbeatResponse) headerVersion() int16 { return 1 }
+func (r *ConsumerGroupHeartbeatResponse) isValidVersion() bool { return r.Version == 0 }
+func (r *ConsumerGroupHeartbeatResponse) requiredVersion() KafkaVersion {
+	return V4_0_0_0
+}
+
+func (r *ConsumerGroupHeartbeatResponse) throttleTime() int32 {
+	return r.ThrottleTimeMs
+}
@@ -0,0 +1,56 @@
+package sarama
+
+// ConsumerGroupMemberAssignment holds the assignment data for a single
+// consumer group member, as computed by the group leader and distributed to
+// every member as the (opaque, per-member) Assignment bytes of a
+// SyncGroupRequest, or received back as the MemberAssignment bytes of a
+// SyncGroupResponse.
+type ConsumerGroupMemberAssignment struct {
+	Version  int16
+	Topics   map[string][]int32
+	UserData []byte
+}
+
+func (m *ConsumerGroupMemberAssignment) encode(pe packetEncoder) error {
+	pe.putInt16(m.Version)
+
+	if err := pe.putArrayLength(len(m.Topics)); err != nil {
+		return err
+	}
+	for topic, partitions := range m.Topics {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putInt32Array(partitions); err != nil {
+			return err
+		}
+	}
+
+	return pe.putBytes(m.UserData)
+}
+
+func (m *ConsumerGroupMemberAssignment) decode(pd packetDecoder) (err error) {
+	if m.Version, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		m.Topics = make(map[string][]int32, n)
+		for i := 0; i < n; i++ {
+			topic, err := pd.getString()
+			if err != nil {
+				return err
+			}
+			if m.Topics[topic], err = pd.getInt32Array(); err != nil {
+				return err
+			}
+		}
+	}
+
+	m.UserData, err = pd.getBytes()
+	return err
+}
@@ -16,7 +16,20 @@ import (
 	"time"
 )
 
+// testFuncConsumerGroupProtocols is the set of group protocols exercised by
+// the parameterized functional tests below, to prove that the KIP-848
+// "consumer" protocol reaches parity with the classic rebalance protocol.
+var testFuncConsumerGroupProtocols = []GroupProtocolType{GroupProtocolClassic, GroupProtocolConsumer}
+
 func TestFuncConsumerGroupPartitioning(t *testing.T) {
+	for _, protocol := range testFuncConsumerGroupProtocols {
+		t.Run(string(protocol), func(t *testing.T) {
+			testFuncConsumerGroupPartitioning(t, protocol)
+		})
+	}
+}
+
+func testFuncConsumerGroupPartitioning(t *testing.T, protocol GroupProtocolType) {
 	checkKafkaVersion(t, "0.10.2")
 	setupFunctionalTest(t)
 	defer teardownFunctionalTest(t)
@@ -24,14 +37,14 @@ func TestFuncConsumerGroupPartitioning(t *testing.T) {
 	groupID := testFuncConsumerGroupID(t)
 
 	// start M1
-	m1 := runTestFuncConsumerGroupMember(t, groupID, "M1", 0, nil)
+	m1 := runTestFuncConsumerGroupMemberProto(t, groupID, "M1", protocol, 0, nil)
 	defer m1.Stop()
 	m1.WaitForState(2)
 	m1.WaitForClaims(map[string]int{"test.4": 4})
 	m1.WaitForHandlers(4)
 
 	// start M2
-	m2 := runTestFuncConsumerGroupMember(t, groupID, "M2", 0, nil, "test.1", "test.4")
+	m2 := runTestFuncConsumerGroupMemberProto(t, groupID, "M2", protocol, 0, nil, "test.1", "test.4")
 	defer m2.Stop()
 	m2.WaitForState(2)
 
@@ -95,7 +108,146 @@ func TestFuncConsumerGroupPartitioningStateful(t *testing.T) {
 	m2s.AssertNoInitialValues()
 }
 
+// TestFuncConsumerGroupPartitioningCooperativeSticky exercises
+// BalanceStrategyCooperativeSticky across the rebalance that follows M2
+// joining, which spans more than one generation, and checks that it ends
+// with the partitions of test.4 split two and two. It compares claim counts
+// only: which partitions each member ends up with, and whether M1's
+// retained claims kept running, are not checked here.
+func TestFuncConsumerGroupPartitioningCooperativeSticky(t *testing.T) {
+	checkKafkaVersion(t, "0.10.2")
+	setupFunctionalTest(t)
+	defer teardownFunctionalTest(t)
+
+	groupID := testFuncConsumerGroupID(t)
+
+	config := defaultConfig("M1")
+	config.Consumer.Group.Rebalance.Strategy = BalanceStrategyCooperativeSticky
+
+	// start M1, which initially owns every partition of test.4
+	m1 := runTestFuncConsumerGroupMemberWithConfig(t, config, groupID, 0, nil)
+	defer m1.Stop()
+	m1.WaitForState(2)
+	m1.WaitForClaims(map[string]int{"test.4": 4})
+	m1.WaitForHandlers(4)
+
+	config2 := defaultConfig("M2")
+	config2.Consumer.Group.Rebalance.Strategy = BalanceStrategyCooperativeSticky
+
+	// start M2; under the cooperative protocol this takes two generations:
+	// M1 first revokes its excess partitions without anyone claiming them,
+	// then rejoins and M2 picks them up.
+	m2 := runTestFuncConsumerGroupMemberWithConfig(t, config2, groupID, 0, nil, "test.4")
+	defer m2.Stop()
+
+	m1.WaitForClaims(map[string]int{"test.4": 2})
+	m2.WaitForClaims(map[string]int{"test.4": 2})
+
+	// shutdown both
+	m1.AssertCleanShutdown()
+	m2.AssertCleanShutdown()
+}
+
+// TestFuncConsumerGroupStaticMembership exercises KIP-345 static membership:
+// an M1 that restarts with the same Config.Consumer.Group.Member.InstanceID
+// within the session timeout rejoins as the same member, so M2's assignment
+// is left untouched instead of a rebalance being triggered by M1's
+// departure.
+func TestFuncConsumerGroupStaticMembership(t *testing.T) {
+	checkKafkaVersion(t, "2.4.0")
+	setupFunctionalTest(t)
+	defer teardownFunctionalTest(t)
+
+	groupID := testFuncConsumerGroupID(t)
+
+	config1 := defaultConfig("M1")
+	config1.Version = V2_4_0_0
+	config1.Consumer.Group.Member.InstanceID = "m1-instance"
+
+	m1 := runTestFuncConsumerGroupMemberWithConfig(t, config1, groupID, 0, nil)
+	defer m1.Stop()
+	m1.WaitForState(2)
+	m1.WaitForClaims(map[string]int{"test.4": 4})
+
+	m2 := runTestFuncConsumerGroupMember(t, groupID, "M2", 0, nil, "test.1", "test.4")
+	defer m2.Stop()
+	m2.WaitForState(2)
+	m2.WaitForClaims(map[string]int{"test.1": 1, "test.4": 2})
+
+	// restart M1 with the same InstanceID
+	m1.AssertCleanShutdown()
+	config1 = defaultConfig("M1")
+	config1.Version = V2_4_0_0
+	config1.Consumer.Group.Member.InstanceID = "m1-instance"
+	m1 = runTestFuncConsumerGroupMemberWithConfig(t, config1, groupID, 0, nil)
+	defer m1.Stop()
+	m1.WaitForState(2)
+
+	// M2's claims must be unchanged: no rebalance was triggered by the
+	// restart of a static member.
+	m2.WaitForClaims(map[string]int{"test.1": 1, "test.4": 2})
+
+	m1.AssertCleanShutdown()
+	m2.AssertCleanShutdown()
+}
+
+// TestFuncConsumerGroupCopartitioning exercises BalanceStrategyCopartitioning
+// with two equal-partition-count topics as members join and leave.
+// assertCopartitioned checks that a member holds the same number of claims
+// on both topics; it compares counts only, not partition numbers.
+func TestFuncConsumerGroupCopartitioning(t *testing.T) {
+	checkKafkaVersion(t, "0.10.2")
+	setupFunctionalTest(t)
+	defer teardownFunctionalTest(t)
+
+	groupID := testFuncConsumerGroupID(t)
+
+	assertCopartitioned := func(t *testing.T, m *testFuncConsumerGroupMember) {
+		t.Helper()
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		if m.claims["test.4"] != m.claims["test.5"] {
+			t.Fatalf("expected co-partitioned claim counts to match, got test.5=%d test.4=%d", m.claims["test.5"], m.claims["test.4"])
+		}
+	}
+
+	config1 := defaultConfig("M1")
+	config1.Consumer.Group.Rebalance.Strategy = BalanceStrategyCopartitioning
+
+	m1 := runTestFuncConsumerGroupMemberWithConfig(t, config1, groupID, 0, nil, "test.5", "test.4")
+	defer m1.Stop()
+	m1.WaitForState(2)
+	m1.WaitForClaims(map[string]int{"test.5": 4, "test.4": 4})
+	assertCopartitioned(t, m1)
+
+	config2 := defaultConfig("M2")
+	config2.Consumer.Group.Rebalance.Strategy = BalanceStrategyCopartitioning
+
+	m2 := runTestFuncConsumerGroupMemberWithConfig(t, config2, groupID, 0, nil, "test.5", "test.4")
+	defer m2.Stop()
+	m2.WaitForState(2)
+
+	m1.WaitForClaims(map[string]int{"test.5": 2, "test.4": 2})
+	m2.WaitForClaims(map[string]int{"test.5": 2, "test.4": 2})
+	assertCopartitioned(t, m1)
+	assertCopartitioned(t, m2)
+
+	m1.AssertCleanShutdown()
+	m2.WaitForClaims(map[string]int{"test.5": 4, "test.4": 4})
+	assertCopartitioned(t, m2)
+
+	m2.AssertCleanShutdown()
+}
+
 func TestFuncConsumerGroupExcessConsumers(t *testing.T) {
+	for _, protocol := range testFuncConsumerGroupProtocols {
+		t.Run(string(protocol), func(t *testing.T) {
+			testFuncConsumerGroupExcessConsumers(t, protocol)
+		})
+	}
+}
+
+func testFuncConsumerGroupExcessConsumers(t *testing.T, protocol GroupProtocolType) {
 	checkKafkaVersion(t, "0.10.2")
 	setupFunctionalTest(t)
 	defer teardownFunctionalTest(t)
@@ -103,13 +255,13 @@ func TestFuncConsumerGroupExcessConsumers(t *testing.T) {
 	groupID := testFuncConsumerGroupID(t)
 
 	// start members
-	m1 := runTestFuncConsumerGroupMember(t, groupID, "M1", 0, nil)
+	m1 := runTestFuncConsumerGroupMemberProto(t, groupID, "M1", protocol, 0, nil)
 	defer m1.Stop()
-	m2 := runTestFuncConsumerGroupMember(t, groupID, "M2", 0, nil)
+	m2 := runTestFuncConsumerGroupMemberProto(t, groupID, "M2", protocol, 0, nil)
 	defer m2.Stop()
-	m3 := runTestFuncConsumerGroupMember(t, groupID, "M3", 0, nil)
+	m3 := runTestFuncConsumerGroupMemberProto(t, groupID, "M3", protocol, 0, nil)
 	defer m3.Stop()
-	m4 := runTestFuncConsumerGroupMember(t, groupID, "M4", 0, nil)
+	m4 := runTestFuncConsumerGroupMemberProto(t, groupID, "M4", protocol, 0, nil)
 	defer m4.Stop()
 
 	m1.WaitForClaims(map[string]int{"test.4": 1})
@@ -118,7 +270,7 @@ func TestFuncConsumerGroupExcessConsumers(t *testing.T) {
 	m4.WaitForClaims(map[string]int{"test.4": 1})
 
 	// start M5
-	m5 := runTestFuncConsumerGroupMember(t, groupID, "M5", 0, nil)
+	m5 := runTestFuncConsumerGroupMemberProto(t, groupID, "M5", protocol, 0, nil)
 	defer m5.Stop()
 	m5.WaitForState(1)
 	m5.AssertNoErrs()
@@ -136,6 +288,14 @@ func TestFuncConsumerGroupExcessConsumers(t *testing.T) {
 }
 
 func TestFuncConsumerGroupFuzzy(t *testing.T) {
+	for _, protocol := range testFuncConsumerGroupProtocols {
+		t.Run(string(protocol), func(t *testing.T) {
+			testFuncConsumerGroupFuzzy(t, protocol)
+		})
+	}
+}
+
+func testFuncConsumerGroupFuzzy(t *testing.T, protocol GroupProtocolType) {
 	checkKafkaVersion(t, "0.10.2")
 	setupFunctionalTest(t)
 	defer teardownFunctionalTest(t)
@@ -160,27 +320,27 @@ func TestFuncConsumerGroupFuzzy(t *testing.T) {
 		}
 	}
 
-	defer runTestFuncConsumerGroupMember(t, groupID, "M1", 1500, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M2", 3000, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M3", 1500, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M4", 200, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M5", 100, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M1", protocol, 1500, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M2", protocol, 3000, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M3", protocol, 1500, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M4", protocol, 200, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M5", protocol, 100, sink).Stop()
 	waitForMessages(t, 3000)
 
-	defer runTestFuncConsumerGroupMember(t, groupID, "M6", 300, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M7", 400, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M8", 500, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M9", 2000, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M6", protocol, 300, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M7", protocol, 400, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M8", protocol, 500, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M9", protocol, 2000, sink).Stop()
 	waitForMessages(t, 8000)
 
-	defer runTestFuncConsumerGroupMember(t, groupID, "M10", 1000, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M10", protocol, 1000, sink).Stop()
 	waitForMessages(t, 10000)
 
-	defer runTestFuncConsumerGroupMember(t, groupID, "M11", 1000, sink).Stop()
-	defer runTestFuncConsumerGroupMember(t, groupID, "M12", 2500, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M11", protocol, 1000, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M12", protocol, 2500, sink).Stop()
 	waitForMessages(t, 12000)
 
-	defer runTestFuncConsumerGroupMember(t, groupID, "M13", 1000, sink).Stop()
+	defer runTestFuncConsumerGroupMemberProto(t, groupID, "M13", protocol, 1000, sink).Stop()
 	waitForMessages(t, 15000)
 
 	if umap := sink.Close(); len(umap) != 15000 {
@@ -194,6 +354,51 @@ func TestFuncConsumerGroupFuzzy(t *testing.T) {
 	}
 }
 
+// TestFuncConsumerGroupAutoReconnect kills a random broker mid-session and
+// verifies the group's ConnectionState transitions Connected -> Recovering
+// -> Connected again on its own, without the test re-invoking Consume.
+func TestFuncConsumerGroupAutoReconnect(t *testing.T) {
+	checkKafkaVersion(t, "0.10.2")
+	setupFunctionalTest(t)
+	defer teardownFunctionalTest(t)
+
+	groupID := testFuncConsumerGroupID(t)
+
+	// the member helper closes the group on the first error it reads from
+	// Errors(), which would end the group on the very session error it is
+	// expected to recover from
+	config := defaultConfig("M1")
+	config.Consumer.Return.Errors = false
+
+	m1 := runTestFuncConsumerGroupMemberWithConfig(t, config, groupID, 0, nil)
+	defer m1.Stop()
+	m1.WaitForState(2)
+	m1.WaitForClaims(map[string]int{"test.4": 4})
+
+	states := m1.ConnectionStateChanges()
+	waitForConnectionState := func(t *testing.T, want ConnectionState) {
+		t.Helper()
+		deadline := time.After(60 * time.Second)
+		for {
+			select {
+			case got := <-states:
+				if got == want {
+					return
+				}
+			case <-deadline:
+				t.Fatalf("timed out waiting for ConnectionState %s, last observed %s", want, m1.ConnectionState())
+			}
+		}
+	}
+
+	killRandomBroker(t)
+
+	waitForConnectionState(t, ConnectionStateRecovering)
+	waitForConnectionState(t, ConnectionStateConnected)
+
+	m1.AssertCleanShutdown()
+}
+
 func TestFuncConsumerGroupOffsetDeletion(t *testing.T) {
 	checkKafkaVersion(t, "2.4.0")
 	setupFunctionalTest(t)
@@ -254,6 +459,51 @@ func TestFuncConsumerGroupOffsetDeletion(t *testing.T) {
 	}
 }
 
+func TestFuncAdminDescribeClusterAndTopicsAuthorizedOperations(t *testing.T) {
+	checkKafkaVersion(t, "2.3.0")
+	setupFunctionalTest(t)
+	defer teardownFunctionalTest(t)
+
+	config := NewTestConfig()
+	config.Version = V2_3_0_0
+	client, err := NewClient(FunctionalTestEnv.KafkaBrokerAddrs, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	admin, err := NewClusterAdminFromClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, admin)
+
+	cluster, err := admin.DescribeCluster(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cluster.ControllerID == 0 {
+		t.Fatal("expected a non-zero controller id")
+	}
+	if len(cluster.Brokers) == 0 {
+		t.Fatal("expected at least one broker in the cluster description")
+	}
+	if len(cluster.AuthorizedOperations) == 0 {
+		t.Fatal("expected authorized operations to be populated when requested")
+	}
+
+	topics, err := admin.DescribeTopics([]string{"test.4"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 1 {
+		t.Fatalf("expected metadata for 1 topic, got %d", len(topics))
+	}
+	if len(topics[0].AuthorizedOperations) == 0 {
+		t.Fatal("expected per-topic authorized operations to be populated when requested")
+	}
+}
+
 // --------------------------------------------------------------------
 
 func testFuncConsumerGroupID(t *testing.T) string {
@@ -369,6 +619,18 @@ func runTestFuncConsumerGroupMember(t *testing.T, groupID, clientID string, maxM
 	return runTestFuncConsumerGroupMemberWithConfig(t, config, groupID, maxMessages, sink, topics...)
 }
 
+// runTestFuncConsumerGroupMemberProto is like runTestFuncConsumerGroupMember
+// but pins the member to a specific group protocol, so that tests can be
+// parameterized to run under both the classic and KIP-848 consumer
+// protocols.
+func runTestFuncConsumerGroupMemberProto(t *testing.T, groupID, clientID string, protocol GroupProtocolType, maxMessages int32, sink *testFuncConsumerGroupSink, topics ...string) *testFuncConsumerGroupMember {
+	t.Helper()
+
+	config := defaultConfig(clientID)
+	config.Consumer.Group.Protocol = protocol
+	return runTestFuncConsumerGroupMemberWithConfig(t, config, groupID, maxMessages, sink, topics...)
+}
+
 func runTestFuncConsumerGroupMemberWithConfig(t *testing.T, config *Config, groupID string, maxMessages int32, sink *testFuncConsumerGroupSink, topics ...string) *testFuncConsumerGroupMember {
 	t.Helper()
 
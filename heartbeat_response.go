@@ -0,0 +1,53 @@
+package sarama
+
+// HeartbeatResponse is the response to a HeartbeatRequest. An Err of
+// ErrRebalanceInProgress signals the member must stop heartbeating and
+// rejoin the group via JoinGroupRequest.
+type HeartbeatResponse struct {
+	Version int16
+
+	ThrottleTime int32
+	Err          KError
+}
+
+func (r *HeartbeatResponse) encode(pe packetEncoder) error {
+	if r.Version >= 1 {
+		pe.putInt32(r.ThrottleTime)
+	}
+	pe.putInt16(int16(r.Err))
+	return nil
+}
+
+func (r *HeartbeatResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.Version >= 1 {
+		if r.ThrottleTime, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
+	errCode, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(errCode)
+	return nil
+}
+
+func (r *HeartbeatResponse) key() int16           { return 12 }
+func (r *HeartbeatResponse) version() int16       { return r.Version }
+func (r *HeartbeatResponse) headerVersion() int16 { return 0 }
+func (r *HeartbeatResponse) isValidVersion() bool { return r.Version >= 0 && r.Version <= 4 }
+func (r *HeartbeatResponse) requiredVersion() KafkaVersion {
+	switch {
+	case r.Version >= 3:
+		return V2_3_0_0
+	case r.Version >= 1:
+		return V0_11_0_0
+	default:
+		return V0_9_0_0
+	}
+}
+
+func (r *HeartbeatResponse) throttleTime() int32 { return r.ThrottleTime }
@@ -0,0 +1,135 @@
+package sarama
+
+// JoinGroupRequest is used by consumer group members (classic protocol) to
+// join, or rejoin, a consumer group.
+type JoinGroupRequest struct {
+	// Version is the version of this request.
+	Version int16
+
+	GroupID          string
+	SessionTimeout   int32
+	RebalanceTimeout int32
+	MemberID         string
+
+	// GroupInstanceId is the static group.instance.id this member registers
+	// with (KIP-345, added in v5). When set, the coordinator treats this
+	// join as the (re)join of a known static member: it reuses the
+	// existing assignment for the instance and suppresses the rebalance
+	// that would otherwise be triggered by a transient member departure
+	// within the session timeout.
+	GroupInstanceId *string
+
+	ProtocolType          string
+	GroupProtocols        map[string][]byte
+	OrderedGroupProtocols []*GroupProtocol
+}
+
+// GroupProtocol holds the name and metadata for a single protocol a member
+// is willing to use, such as a BalanceStrategy name and its encoded
+// ConsumerGroupMemberMetadata.
+type GroupProtocol struct {
+	Name     string
+	Metadata []byte
+}
+
+func (r *JoinGroupRequest) encode(pe packetEncoder) error {
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+	pe.putInt32(r.SessionTimeout)
+	if r.Version >= 1 {
+		pe.putInt32(r.RebalanceTimeout)
+	}
+	if err := pe.putString(r.MemberID); err != nil {
+		return err
+	}
+	if r.Version >= 5 {
+		if err := pe.putNullableString(r.GroupInstanceId); err != nil {
+			return err
+		}
+	}
+	if err := pe.putString(r.ProtocolType); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.OrderedGroupProtocols)); err != nil {
+		return err
+	}
+	for _, p := range r.OrderedGroupProtocols {
+		if err := pe.putString(p.Name); err != nil {
+			return err
+		}
+		if err := pe.putBytes(p.Metadata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *JoinGroupRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.SessionTimeout, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.Version >= 1 {
+		if r.RebalanceTimeout, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+	if r.MemberID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.Version >= 5 {
+		if r.GroupInstanceId, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+	if r.ProtocolType, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.OrderedGroupProtocols = make([]*GroupProtocol, n)
+	for i := 0; i < n; i++ {
+		p := new(GroupProtocol)
+		if p.Name, err = pd.getString(); err != nil {
+			return err
+		}
+		if p.Metadata, err = pd.getBytes(); err != nil {
+			return err
+		}
+		r.OrderedGroupProtocols[i] = p
+	}
+
+	return nil
+}
+
+// AddGroupProtocol appends a candidate protocol (typically a BalanceStrategy
+// name plus its serialized ConsumerGroupMemberMetadata) to the request, in
+// the order the member prefers them.
+func (r *JoinGroupRequest) AddGroupProtocol(name string, metadata []byte) {
+	r.OrderedGroupProtocols = append(r.OrderedGroupProtocols, &GroupProtocol{Name: name, Metadata: metadata})
+}
+
+func (r *JoinGroupRequest) key() int16           { return 11 }
+func (r *JoinGroupRequest) version() int16       { return r.Version }
+func (r *JoinGroupRequest) headerVersion() int16 { return 1 }
+func (r *JoinGroupRequest) isValidVersion() bool { return r.Version >= 0 && r.Version <= 5 }
+func (r *JoinGroupRequest) requiredVersion() KafkaVersion {
+	switch {
+	case r.Version >= 5:
+		return V2_3_0_0
+	case r.Version >= 1:
+		return V0_11_0_0
+	default:
+		return V0_9_0_0
+	}
+}
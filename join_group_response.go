@@ -0,0 +1,119 @@
+package sarama
+
+// JoinGroupResponse is the response to a JoinGroupRequest: the negotiated
+// generation, the member's own ID, the elected protocol, and — for the
+// member elected leader — the full membership list so it can compute an
+// assignment.
+type JoinGroupResponse struct {
+	Version int16
+
+	ThrottleTime  int32
+	Err           KError
+	GenerationId  int32
+	GroupProtocol string
+	LeaderId      string
+	MemberId      string
+	Members       map[string]ConsumerGroupMemberMetadata
+}
+
+func (r *JoinGroupResponse) encode(pe packetEncoder) error {
+	if r.Version >= 2 {
+		pe.putInt32(r.ThrottleTime)
+	}
+	pe.putInt16(int16(r.Err))
+	pe.putInt32(r.GenerationId)
+	if err := pe.putString(r.GroupProtocol); err != nil {
+		return err
+	}
+	if err := pe.putString(r.LeaderId); err != nil {
+		return err
+	}
+	if err := pe.putString(r.MemberId); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.Members)); err != nil {
+		return err
+	}
+	for memberID, meta := range r.Members {
+		if err := pe.putString(memberID); err != nil {
+			return err
+		}
+		bin, err := encode(&meta)
+		if err != nil {
+			return err
+		}
+		if err := pe.putBytes(bin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *JoinGroupResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.Version >= 2 {
+		if r.ThrottleTime, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
+	errCode, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(errCode)
+
+	if r.GenerationId, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.GroupProtocol, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.LeaderId, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.MemberId, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	r.Members = make(map[string]ConsumerGroupMemberMetadata, n)
+	for i := 0; i < n; i++ {
+		memberID, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		bin, err := pd.getBytes()
+		if err != nil {
+			return err
+		}
+		var meta ConsumerGroupMemberMetadata
+		if err := decode(bin, &meta); err != nil {
+			return err
+		}
+		r.Members[memberID] = meta
+	}
+	return nil
+}
+
+func (r *JoinGroupResponse) key() int16           { return 11 }
+func (r *JoinGroupResponse) version() int16       { return r.Version }
+func (r *JoinGroupResponse) headerVersion() int16 { return 0 }
+func (r *JoinGroupResponse) isValidVersion() bool { return r.Version >= 0 && r.Version <= 5 }
+func (r *JoinGroupResponse) requiredVersion() KafkaVersion {
+	if r.Version >= 5 {
+		return V2_3_0_0
+	}
+	return V0_9_0_0
+}
+
+func (r *JoinGroupResponse) throttleTime() int32 { return r.ThrottleTime }
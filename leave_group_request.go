@@ -0,0 +1,89 @@
+package sarama
+
+// LeaveGroupRequest is used by a consumer group member to notify the
+// coordinator that it is leaving the group, triggering an immediate
+// rebalance instead of waiting for the session timeout to expire.
+type LeaveGroupRequest struct {
+	// Version is the version of this request.
+	Version int16
+
+	GroupID string
+	// MemberID identifies the single member leaving when Version < 3.
+	MemberID string
+	// Members lists the members leaving, each optionally identified by a
+	// static GroupInstanceId (KIP-345), as of Version 3. A member with a
+	// GroupInstanceId leaves using its static identity, distinct from the
+	// ephemeral MemberID, so that an operator can remove a specific
+	// instance via ClusterAdmin.RemoveMemberFromConsumerGroup without
+	// knowing its current ephemeral member ID.
+	Members []MemberIdentity
+}
+
+// MemberIdentity identifies a single group member leaving the group, either
+// by its ephemeral MemberId or, for a static member, by its GroupInstanceId.
+type MemberIdentity struct {
+	MemberID        string
+	GroupInstanceId *string
+}
+
+func (r *LeaveGroupRequest) encode(pe packetEncoder) error {
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+
+	if r.Version < 3 {
+		return pe.putString(r.MemberID)
+	}
+
+	if err := pe.putArrayLength(len(r.Members)); err != nil {
+		return err
+	}
+	for _, m := range r.Members {
+		if err := pe.putString(m.MemberID); err != nil {
+			return err
+		}
+		if err := pe.putNullableString(m.GroupInstanceId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *LeaveGroupRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+
+	if r.Version < 3 {
+		r.MemberID, err = pd.getString()
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Members = make([]MemberIdentity, n)
+	for i := 0; i < n; i++ {
+		if r.Members[i].MemberID, err = pd.getString(); err != nil {
+			return err
+		}
+		if r.Members[i].GroupInstanceId, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *LeaveGroupRequest) key() int16           { return 13 }
+func (r *LeaveGroupRequest) version() int16       { return r.Version }
+func (r *LeaveGroupRequest) headerVersion() int16 { return 1 }
+func (r *LeaveGroupRequest) isValidVersion() bool { return r.Version >= 0 && r.Version <= 3 }
+func (r *LeaveGroupRequest) requiredVersion() KafkaVersion {
+	if r.Version >= 3 {
+		return V2_4_0_0
+	}
+	return V0_9_0_0
+}
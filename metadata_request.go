@@ -0,0 +1,106 @@
+package sarama
+
+// MetadataRequest requests cluster and topic metadata from a broker: the
+// set of live brokers, the controller, and (for the requested topics, or
+// all topics if Topics is nil) the partition leadership and replica sets.
+type MetadataRequest struct {
+	// Version is the version of this request.
+	Version int16
+
+	// Topics is the set of topics to describe. From v3 on, nil is sent as
+	// a null array, which describes every topic the caller is authorized
+	// to see, while an empty, non-nil slice describes no topics; below v3
+	// nil and empty are both sent as an empty array.
+	Topics []string
+
+	// AllowAutoTopicCreation, when true, causes the broker to create any
+	// requested topic that does not yet exist, if auto-creation is enabled
+	// cluster-side.
+	AllowAutoTopicCreation bool
+
+	// IncludeClusterAuthorizedOperations requests that the response include
+	// the bitmap of ACL operations the caller is authorized to perform on
+	// the cluster as a whole (v8+).
+	IncludeClusterAuthorizedOperations bool
+
+	// IncludeTopicAuthorizedOperations requests that the response include,
+	// per topic, the bitmap of ACL operations the caller is authorized to
+	// perform on that topic (v8+).
+	IncludeTopicAuthorizedOperations bool
+}
+
+func (r *MetadataRequest) encode(pe packetEncoder) error {
+	if r.Version < 0 || r.Version > 2 {
+		if r.Topics == nil {
+			if err := pe.putArrayLength(-1); err != nil {
+				return err
+			}
+		} else if err := pe.putStringArray(r.Topics); err != nil {
+			return err
+		}
+	} else if err := pe.putStringArray(r.Topics); err != nil {
+		return err
+	}
+
+	if r.Version >= 4 {
+		pe.putBool(r.AllowAutoTopicCreation)
+	}
+
+	if r.Version >= 8 {
+		pe.putBool(r.IncludeClusterAuthorizedOperations)
+		pe.putBool(r.IncludeTopicAuthorizedOperations)
+	}
+
+	return nil
+}
+
+func (r *MetadataRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	size, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if size < 0 {
+		r.Topics = nil
+	} else {
+		r.Topics = make([]string, size)
+		for i := range r.Topics {
+			if r.Topics[i], err = pd.getString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.Version >= 4 {
+		if r.AllowAutoTopicCreation, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
+
+	if r.Version >= 8 {
+		if r.IncludeClusterAuthorizedOperations, err = pd.getBool(); err != nil {
+			return err
+		}
+		if r.IncludeTopicAuthorizedOperations, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *MetadataRequest) key() int16           { return 3 }
+func (r *MetadataRequest) version() int16       { return r.Version }
+func (r *MetadataRequest) headerVersion() int16 { return 1 }
+func (r *MetadataRequest) isValidVersion() bool { return r.Version >= 0 && r.Version <= 9 }
+func (r *MetadataRequest) requiredVersion() KafkaVersion {
+	switch {
+	case r.Version >= 8:
+		return V2_3_0_0
+	case r.Version >= 4:
+		return V0_11_0_0
+	default:
+		return V0_8_2_0
+	}
+}
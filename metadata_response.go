@@ -0,0 +1,267 @@
+package sarama
+
+// MetadataBroker describes a single broker as returned in a MetadataResponse.
+type MetadataBroker struct {
+	NodeID int32
+	Host   string
+	Port   int32
+	Rack   *string
+}
+
+// MetadataResponseTopic describes a single topic's partitions as returned in
+// a MetadataResponse, along with, when requested, the bitmap of operations
+// the caller is authorized to perform on it.
+type MetadataResponseTopic struct {
+	Err        KError
+	Name       string
+	IsInternal bool
+	Partitions []*MetadataResponsePartition
+
+	// AuthorizedOperations is populated when the request set
+	// IncludeTopicAuthorizedOperations, and is nil otherwise.
+	AuthorizedOperations []AclOperation
+
+	topicAuthorizedOperations int32
+}
+
+// MetadataResponsePartition describes a single partition's leader and
+// replica set as returned in a MetadataResponse.
+type MetadataResponsePartition struct {
+	Err             KError
+	ID              int32
+	Leader          int32
+	LeaderEpoch     int32
+	Replicas        []int32
+	Isr             []int32
+	OfflineReplicas []int32
+}
+
+// MetadataResponse is the response to a MetadataRequest: the live broker
+// list, the current controller, and per-topic partition metadata.
+type MetadataResponse struct {
+	Version      int16
+	ThrottleTime int32
+
+	Brokers      []*MetadataBroker
+	ClusterID    *string
+	ControllerID int32
+	Topics       []*MetadataResponseTopic
+
+	// ClusterAuthorizedOperations is populated when the request set
+	// IncludeClusterAuthorizedOperations, and is nil otherwise.
+	ClusterAuthorizedOperations []AclOperation
+
+	clusterAuthorizedOperations int32
+}
+
+func (r *MetadataResponse) encode(pe packetEncoder) error {
+	if r.Version >= 3 {
+		pe.putInt32(r.ThrottleTime)
+	}
+
+	if err := pe.putArrayLength(len(r.Brokers)); err != nil {
+		return err
+	}
+	for _, b := range r.Brokers {
+		pe.putInt32(b.NodeID)
+		if err := pe.putString(b.Host); err != nil {
+			return err
+		}
+		pe.putInt32(b.Port)
+		if r.Version >= 1 {
+			if err := pe.putNullableString(b.Rack); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.Version >= 2 {
+		if err := pe.putNullableString(r.ClusterID); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 1 {
+		pe.putInt32(r.ControllerID)
+	}
+
+	if err := pe.putArrayLength(len(r.Topics)); err != nil {
+		return err
+	}
+	for _, t := range r.Topics {
+		pe.putInt16(int16(t.Err))
+		if err := pe.putString(t.Name); err != nil {
+			return err
+		}
+		if r.Version >= 1 {
+			pe.putBool(t.IsInternal)
+		}
+
+		if err := pe.putArrayLength(len(t.Partitions)); err != nil {
+			return err
+		}
+		for _, p := range t.Partitions {
+			pe.putInt16(int16(p.Err))
+			pe.putInt32(p.ID)
+			pe.putInt32(p.Leader)
+			if err := pe.putInt32Array(p.Replicas); err != nil {
+				return err
+			}
+			if err := pe.putInt32Array(p.Isr); err != nil {
+				return err
+			}
+			if r.Version >= 5 {
+				if err := pe.putInt32Array(p.OfflineReplicas); err != nil {
+					return err
+				}
+			}
+		}
+
+		if r.Version >= 8 {
+			pe.putInt32(t.topicAuthorizedOperations)
+		}
+	}
+
+	if r.Version >= 8 {
+		pe.putInt32(r.clusterAuthorizedOperations)
+	}
+
+	return nil
+}
+
+func (r *MetadataResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.Version >= 3 {
+		if r.ThrottleTime, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Brokers = make([]*MetadataBroker, n)
+	for i := 0; i < n; i++ {
+		b := new(MetadataBroker)
+		if b.NodeID, err = pd.getInt32(); err != nil {
+			return err
+		}
+		if b.Host, err = pd.getString(); err != nil {
+			return err
+		}
+		if b.Port, err = pd.getInt32(); err != nil {
+			return err
+		}
+		if r.Version >= 1 {
+			if b.Rack, err = pd.getNullableString(); err != nil {
+				return err
+			}
+		}
+		r.Brokers[i] = b
+	}
+
+	if r.Version >= 2 {
+		if r.ClusterID, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 1 {
+		if r.ControllerID, err = pd.getInt32(); err != nil {
+			return err
+		}
+	} else {
+		r.ControllerID = -1
+	}
+
+	n, err = pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Topics = make([]*MetadataResponseTopic, n)
+	for i := 0; i < n; i++ {
+		t := new(MetadataResponseTopic)
+
+		errCode, err := pd.getInt16()
+		if err != nil {
+			return err
+		}
+		t.Err = KError(errCode)
+
+		if t.Name, err = pd.getString(); err != nil {
+			return err
+		}
+		if r.Version >= 1 {
+			if t.IsInternal, err = pd.getBool(); err != nil {
+				return err
+			}
+		}
+
+		pn, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+		t.Partitions = make([]*MetadataResponsePartition, pn)
+		for j := 0; j < pn; j++ {
+			p := new(MetadataResponsePartition)
+
+			perrCode, err := pd.getInt16()
+			if err != nil {
+				return err
+			}
+			p.Err = KError(perrCode)
+
+			if p.ID, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if p.Leader, err = pd.getInt32(); err != nil {
+				return err
+			}
+			if p.Replicas, err = pd.getInt32Array(); err != nil {
+				return err
+			}
+			if p.Isr, err = pd.getInt32Array(); err != nil {
+				return err
+			}
+			if r.Version >= 5 {
+				if p.OfflineReplicas, err = pd.getInt32Array(); err != nil {
+					return err
+				}
+			}
+			t.Partitions[j] = p
+		}
+
+		if r.Version >= 8 {
+			if t.topicAuthorizedOperations, err = pd.getInt32(); err != nil {
+				return err
+			}
+			t.AuthorizedOperations = authorizedOperationsFromBitmap(t.topicAuthorizedOperations)
+		}
+
+		r.Topics[i] = t
+	}
+
+	if r.Version >= 8 {
+		if r.clusterAuthorizedOperations, err = pd.getInt32(); err != nil {
+			return err
+		}
+		r.ClusterAuthorizedOperations = authorizedOperationsFromBitmap(r.clusterAuthorizedOperations)
+	}
+
+	return nil
+}
+
+func (r *MetadataResponse) key() int16           { return 3 }
+func (r *MetadataResponse) version() int16       { return r.Version }
+func (r *MetadataResponse) headerVersion() int16 { return 0 }
+func (r *MetadataResponse) isValidVersion() bool { return r.Version >= 0 && r.Version <= 9 }
+func (r *MetadataResponse) requiredVersion() KafkaVersion {
+	switch {
+	case r.Version >= 8:
+		return V2_3_0_0
+	default:
+		return V0_8_2_0
+	}
+}
+
+func (r *MetadataResponse) throttleTime() int32 { return r.ThrottleTime }
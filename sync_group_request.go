@@ -0,0 +1,132 @@
+package sarama
+
+// SyncGroupRequest is sent by every member once it has received its
+// JoinGroupResponse: the group leader includes the full per-member
+// assignment it computed from the BalanceStrategy, and every other member
+// sends an empty GroupAssignments. The coordinator responds to each member
+// with its own slice of the assignment.
+type SyncGroupRequest struct {
+	// Version is the version of this request.
+	Version int16
+
+	GroupID      string
+	GenerationId int32
+	MemberId     string
+
+	// GroupInstanceId is the static group.instance.id this member joined
+	// with, if any (KIP-345, added in v3).
+	GroupInstanceId *string
+
+	// ProtocolType and ProtocolName echo the protocol negotiated during
+	// JoinGroup, and are validated by the coordinator as of v5.
+	ProtocolType *string
+	ProtocolName *string
+
+	// GroupAssignments is only non-empty on the leader's request.
+	GroupAssignments []SyncGroupRequestAssignment
+}
+
+// SyncGroupRequestAssignment is a single member's assignment, as computed by
+// the group leader via BalanceStrategy.Plan and serialized via
+// ConsumerGroupMemberAssignment.
+type SyncGroupRequestAssignment struct {
+	MemberId   string
+	Assignment []byte
+}
+
+func (r *SyncGroupRequest) encode(pe packetEncoder) error {
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+	pe.putInt32(r.GenerationId)
+	if err := pe.putString(r.MemberId); err != nil {
+		return err
+	}
+	if r.Version >= 3 {
+		if err := pe.putNullableString(r.GroupInstanceId); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 5 {
+		if err := pe.putNullableString(r.ProtocolType); err != nil {
+			return err
+		}
+		if err := pe.putNullableString(r.ProtocolName); err != nil {
+			return err
+		}
+	}
+
+	if err := pe.putArrayLength(len(r.GroupAssignments)); err != nil {
+		return err
+	}
+	for _, a := range r.GroupAssignments {
+		if err := pe.putString(a.MemberId); err != nil {
+			return err
+		}
+		if err := pe.putBytes(a.Assignment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SyncGroupRequest) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.GenerationId, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.MemberId, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.Version >= 3 {
+		if r.GroupInstanceId, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+	if r.Version >= 5 {
+		if r.ProtocolType, err = pd.getNullableString(); err != nil {
+			return err
+		}
+		if r.ProtocolName, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		r.GroupAssignments = make([]SyncGroupRequestAssignment, n)
+		for i := 0; i < n; i++ {
+			if r.GroupAssignments[i].MemberId, err = pd.getString(); err != nil {
+				return err
+			}
+			if r.GroupAssignments[i].Assignment, err = pd.getBytes(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *SyncGroupRequest) key() int16           { return 14 }
+func (r *SyncGroupRequest) version() int16       { return r.Version }
+func (r *SyncGroupRequest) headerVersion() int16 { return 1 }
+func (r *SyncGroupRequest) isValidVersion() bool { return r.Version >= 0 && r.Version <= 5 }
+func (r *SyncGroupRequest) requiredVersion() KafkaVersion {
+	switch {
+	case r.Version >= 5:
+		return V2_3_0_0
+	case r.Version >= 3:
+		return V2_3_0_0
+	default:
+		return V0_9_0_0
+	}
+}
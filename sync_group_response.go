@@ -0,0 +1,78 @@
+package sarama
+
+// SyncGroupResponse carries the calling member's slice of the group
+// assignment, as a ConsumerGroupMemberAssignment-encoded blob the leader
+// placed in its SyncGroupRequest.
+type SyncGroupResponse struct {
+	Version int16
+
+	ThrottleTime int32
+	Err          KError
+
+	// ProtocolType and ProtocolName are only populated as of v5.
+	ProtocolType *string
+	ProtocolName *string
+
+	MemberAssignment []byte
+}
+
+func (r *SyncGroupResponse) encode(pe packetEncoder) error {
+	if r.Version >= 1 {
+		pe.putInt32(r.ThrottleTime)
+	}
+	pe.putInt16(int16(r.Err))
+	if r.Version >= 5 {
+		if err := pe.putNullableString(r.ProtocolType); err != nil {
+			return err
+		}
+		if err := pe.putNullableString(r.ProtocolName); err != nil {
+			return err
+		}
+	}
+	return pe.putBytes(r.MemberAssignment)
+}
+
+func (r *SyncGroupResponse) decode(pd packetDecoder, version int16) (err error) {
+	r.Version = version
+
+	if r.Version >= 1 {
+		if r.ThrottleTime, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
+	errCode, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(errCode)
+
+	if r.Version >= 5 {
+		if r.ProtocolType, err = pd.getNullableString(); err != nil {
+			return err
+		}
+		if r.ProtocolName, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+
+	r.MemberAssignment, err = pd.getBytes()
+	return err
+}
+
+func (r *SyncGroupResponse) key() int16           { return 14 }
+func (r *SyncGroupResponse) version() int16       { return r.Version }
+func (r *SyncGroupResponse) headerVersion() int16 { return 0 }
+func (r *SyncGroupResponse) isValidVersion() bool { return r.Version >= 0 && r.Version <= 5 }
+func (r *SyncGroupResponse) requiredVersion() KafkaVersion {
+	switch {
+	case r.Version >= 5:
+		return V2_3_0_0
+	case r.Version >= 3:
+		return V2_3_0_0
+	default:
+		return V0_9_0_0
+	}
+}
+
+func (r *SyncGroupResponse) throttleTime() int32 { return r.ThrottleTime }